@@ -24,6 +24,10 @@ func (a *AstPrinter) VisitCallExpr(expr *CallExpr) string {
 	return a.parenthesize("function", expr.callee)
 }
 
+func (a *AstPrinter) VisitFunctionExpr(expr *FunctionExpr) string {
+	return "(fun)"
+}
+
 func (a *AstPrinter) VisitGroupingExpr(grouping *GroupingExpr) string {
 	return a.parenthesize("group", grouping.expression)
 }
@@ -59,6 +63,26 @@ func (a *AstPrinter) VisitSetExpr(setexpr *SetExpr) string {
 	return a.parenthesize("set", setexpr.object, setexpr.name, setexpr.value)
 }
 
+func (a *AstPrinter) VisitIndexExpr(indexexpr *IndexExpr) string {
+	return a.parenthesize("index", indexexpr.object, indexexpr.index)
+}
+
+func (a *AstPrinter) VisitIndexSetExpr(indexsetexpr *IndexSetExpr) string {
+	return a.parenthesize("index-set", indexsetexpr.object, indexsetexpr.index, indexsetexpr.value)
+}
+
+func (a *AstPrinter) VisitListExpr(listexpr *ListExpr) string {
+	return a.parenthesize("list", listexpr.elements...)
+}
+
+func (a *AstPrinter) VisitMapExpr(mapexpr *MapExpr) string {
+	exprs := make([]Expr, 0, len(mapexpr.keys)*2)
+	for index := range mapexpr.keys {
+		exprs = append(exprs, mapexpr.keys[index], mapexpr.values[index])
+	}
+	return a.parenthesize("map", exprs...)
+}
+
 func (a *AstPrinter) VisitThisExpr(thisexpr *ThisExpr) string {
 	return a.parenthesize("this", thisexpr.keyword)
 }
@@ -0,0 +1,193 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrBudgetExceeded 表示脚本用完了步数或时间预算
+var ErrBudgetExceeded = errors.New("execution budget exceeded")
+
+// 每隔这么多步才检查一次时间和 context，避免频繁调用 time.Now
+const budgetCheckInterval = 256
+
+// WithMaxSteps 限制每次 Run 或 Call 最多执行的步数，每条语句和每次函数调用各算一步，0 表示不限制
+func WithMaxSteps(steps int) Option {
+	return func(vm *VM) {
+		vm.interpreter.maxSteps = steps
+	}
+}
+
+// WithTimeout 限制每次 Run 或 Call 的执行时间，0 表示不限制
+func WithTimeout(timeout time.Duration) Option {
+	return func(vm *VM) {
+		vm.interpreter.timeout = timeout
+	}
+}
+
+// WithMaxCallDepth 设置最大函数调用深度，超出时抛出 "Stack overflow." 运行时错误，<= 0 表示不限制
+func WithMaxCallDepth(depth int) Option {
+	return func(vm *VM) {
+		vm.interpreter.maxCallDepth = depth
+	}
+}
+
+// enter 在从宿主进入脚本时调用，返回的函数在回到宿主时调用。
+// 只有最外层的进入会重置预算、context 和调用栈；原生函数回调 Lox 时沿用外层的预算，
+// 脚本不能借此绕过步数、时间限制和取消，回调结束后恢复外层的调用栈
+func (i *Interpreter) enter(ctx context.Context) func() {
+	if i.entered == 0 {
+		i.begin(ctx)
+	}
+	i.entered++
+	frames := i.frames
+	return func() {
+		i.entered--
+		i.frames = frames
+	}
+}
+
+// begin 重置预算
+func (i *Interpreter) begin(ctx context.Context) {
+	i.ctx = ctx
+	i.steps = 0
+	i.frames = nil
+	if i.timeout > 0 {
+		i.deadline = time.Now().Add(i.timeout)
+	} else {
+		i.deadline = time.Time{}
+	}
+}
+
+// step 计一步，超出预算或 context 结束时返回错误信息和原因
+func (i *Interpreter) step() (string, error) {
+	i.steps++
+	if i.maxSteps > 0 && i.steps > i.maxSteps {
+		return "Execution step limit exceeded.", ErrBudgetExceeded
+	}
+	if i.steps%budgetCheckInterval != 0 {
+		return "", nil
+	}
+	if !i.deadline.IsZero() && time.Now().After(i.deadline) {
+		return "Execution time limit exceeded.", ErrBudgetExceeded
+	}
+	if i.ctx != nil {
+		if err := i.ctx.Err(); err != nil {
+			return "Execution canceled.", err
+		}
+	}
+	return "", nil
+}
+
+func newBudgetError(token *Token, message string, err error) *RuntimeError {
+	if token == nil {
+		token = NewToken(TokenType_EOF, "", nil, 0)
+	}
+	e := NewRuntimeError(token, message)
+	e.Err = err
+	return e
+}
+
+// stmtToken 找出语句中最靠前的一个 token，用来报告语句所在的行，找不到时返回 nil
+func stmtToken(stmt Stmt) *Token {
+	switch s := stmt.(type) {
+	case *BlockStmt:
+		for _, statement := range s.statements {
+			if token := stmtToken(statement); token != nil {
+				return token
+			}
+		}
+	case *BreakStmt:
+		return s.keyword
+	case *ClassStmt:
+		return s.name
+	case *ContinueStmt:
+		return s.keyword
+	case *ExportStmt:
+		return s.keyword
+	case *ExpressionStmt:
+		return exprToken(s.expression)
+	case *FunctionStmt:
+		return s.name
+	case *ImportStmt:
+		return s.keyword
+	case *IfStmt:
+		if token := exprToken(s.condition); token != nil {
+			return token
+		}
+		return stmtToken(s.thenBranch)
+	case *PrintStmt:
+		return exprToken(s.expression)
+	case *ReturnStmt:
+		return s.keyword
+	case *ThrowStmt:
+		return s.keyword
+	case *TryStmt:
+		return s.keyword
+	case *VarStmt:
+		return s.name
+	case *WhileStmt:
+		return s.keyword
+	}
+	return nil
+}
+
+func exprToken(expr Expr) *Token {
+	switch e := expr.(type) {
+	case *AssignExpr:
+		return e.name
+	case *BinaryExpr:
+		if token := exprToken(e.left); token != nil {
+			return token
+		}
+		return e.operator
+	case *CallExpr:
+		if token := exprToken(e.callee); token != nil {
+			return token
+		}
+		return e.paren
+	case *GetExpr:
+		if token := exprToken(e.object); token != nil {
+			return token
+		}
+		return e.name
+	case *FunctionExpr:
+		return e.keyword
+	case *GroupingExpr:
+		return exprToken(e.expression)
+	case *IndexExpr:
+		if token := exprToken(e.object); token != nil {
+			return token
+		}
+		return e.bracket
+	case *IndexSetExpr:
+		if token := exprToken(e.object); token != nil {
+			return token
+		}
+		return e.bracket
+	case *ListExpr:
+		return e.bracket
+	case *MapExpr:
+		return e.brace
+	case *LogicalExpr:
+		if token := exprToken(e.left); token != nil {
+			return token
+		}
+		return e.operator
+	case *SetExpr:
+		if token := exprToken(e.object); token != nil {
+			return token
+		}
+		return e.name
+	case *SuperExpr:
+		return e.keyword
+	case *ThisExpr:
+		return e.keyword
+	case *UnaryExpr:
+		return e.operator
+	case *VariableExpr:
+		return e.name
+	}
+	return nil
+}
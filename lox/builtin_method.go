@@ -0,0 +1,35 @@
+package lox
+
+import (
+	"fmt"
+)
+
+// builtinMethod 是内置类型（列表、map、字符串）上绑定了接收者的方法
+type builtinMethod struct {
+	class    string
+	name     string
+	minArity int
+	maxArity int
+	fn       func(i *Interpreter, paren *Token, arguments []interface{}) interface{}
+}
+
+func (m *builtinMethod) Arity() int {
+	return m.minArity
+}
+
+func (m *builtinMethod) Call(interpreter *Interpreter, arguments []interface{}) interface{} {
+	return m.callAt(interpreter, NewToken(TokenType_IDENTIFIER, m.name, nil, 0), arguments)
+}
+
+func (m *builtinMethod) callAt(interpreter *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	if m.minArity == m.maxArity {
+		checkArity(paren, m.minArity, len(arguments))
+	} else if len(arguments) < m.minArity || len(arguments) > m.maxArity {
+		panic(NewRuntimeError(paren, fmt.Sprintf("Expected %d to %d arguments but got %d.", m.minArity, m.maxArity, len(arguments))))
+	}
+	return m.fn(interpreter, paren, arguments)
+}
+
+func (m *builtinMethod) String() string {
+	return "<native fn " + m.name + ">"
+}
@@ -7,3 +7,15 @@ type LoxCallable interface {
 	Arity() int
 	Call(interpreter *Interpreter, arguments []interface{}) interface{}
 }
+
+// callSiteCallable 由需要在调用处报告参数错误的可调用对象实现，
+// 它们自己检查参数个数，VisitCallExpr 会优先通过 callAt 调用。
+type callSiteCallable interface {
+	callAt(interpreter *Interpreter, paren *Token, arguments []interface{}) interface{}
+}
+
+func checkArity(paren *Token, arity int, count int) {
+	if count != arity {
+		panic(NewRuntimeError(paren, fmt.Sprintf("Expected %d arguments but got %d.", arity, count)))
+	}
+}
@@ -4,6 +4,8 @@ type LoxClass struct {
 	name       string
 	methods    map[string]*LoxFunction
 	superclass *LoxClass
+	// 继承链最顶端的原生类，没有则为 nil
+	native *NativeClass
 }
 
 func NewLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunction) *LoxClass {
@@ -12,6 +14,9 @@ func NewLoxClass(name string, superclass *LoxClass, methods map[string]*LoxFunct
 		methods:    methods,
 		superclass: superclass,
 	}
+	if superclass != nil {
+		l.native = superclass.native
+	}
 	return l
 }
 
@@ -24,14 +29,32 @@ func (l *LoxClass) Arity() int {
 	if initializer != nil {
 		return initializer.Arity()
 	}
+	if l.native != nil {
+		return l.native.Arity()
+	}
 	return 0
 }
 
 func (l *LoxClass) Call(interpreter *Interpreter, arguments []interface{}) interface{} {
+	return l.callAt(interpreter, NewToken(TokenType_IDENTIFIER, l.name, nil, 0), arguments)
+}
+
+func (l *LoxClass) callAt(interpreter *Interpreter, paren *Token, arguments []interface{}) interface{} {
 	instance := NewLoxInstance(l)
 	initializer := l.FindMethod("init")
+	if l.native != nil {
+		// 继承原生类时，没有 init 就直接用原生构造函数；否则先放一个零值，等 super.init 替换
+		if initializer == nil {
+			instance.native = l.native.construct(interpreter, paren, arguments)
+			return instance
+		}
+		instance.native = l.native.zero()
+	}
 	if initializer != nil {
+		checkArity(paren, initializer.Arity(), len(arguments))
 		initializer.Bind(instance).Call(interpreter, arguments)
+	} else {
+		checkArity(paren, 0, len(arguments))
 	}
 	return instance
 }
@@ -0,0 +1,179 @@
+package lox
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// decimalDivisionScale 是除不尽时保留的小数位数，按银行家舍入法舍入
+const decimalDivisionScale = 20
+
+var bigTen = big.NewInt(10)
+
+// Decimal 是精确的十进制小数，值为 unscaled × 10^-scale。
+// 和 float64 不同，0.1 + 0.2 的结果就是 0.3；加、减、乘的结果总是精确的，小数位数会被保留，例如 1.10 + 1 = 2.10。
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// NewDecimal 解析十进制小数，例如 "19.99"、"-0.5"、"1.5e3"
+func NewDecimal(s string) (*Decimal, error) {
+	d, ok := parseDecimalText(strings.ReplaceAll(s, "_", ""))
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal '%s'", s)
+	}
+	return d, nil
+}
+
+// 小数指数的范围，超出时展开成整数或者计算小数位都会占用大量时间和内存
+const maxDecimalExponent = 10000
+
+func parseDecimalText(s string) (*Decimal, bool) {
+	mantissa, exponent := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		n, err := strconv.Atoi(s[i+1:])
+		if err != nil || n > maxDecimalExponent || n < -maxDecimalExponent {
+			return nil, false
+		}
+		mantissa, exponent = s[:i], n
+	}
+
+	scale := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = len(mantissa) - i - 1
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+	unscaled, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return nil, false
+	}
+
+	d := &Decimal{unscaled: unscaled, scale: scale - exponent}
+	if d.scale < 0 {
+		d = &Decimal{unscaled: d.rescale(0), scale: 0}
+	}
+	return d, true
+}
+
+func decimalFromInt(n *big.Int) *Decimal {
+	return &Decimal{unscaled: new(big.Int).Set(n), scale: 0}
+}
+
+// decimalFromRat 把有理数转换成小数，能精确表示时取最少的小数位，否则舍入到 decimalDivisionScale 位
+func decimalFromRat(r *big.Rat) *Decimal {
+	denominator := new(big.Int).Set(r.Denom())
+	scale := 0
+	for _, factor := range []int64{2, 5} {
+		count := 0
+		f := big.NewInt(factor)
+		m := new(big.Int)
+		for {
+			q, rem := new(big.Int).QuoRem(denominator, f, m)
+			if rem.Sign() != 0 {
+				break
+			}
+			denominator = q
+			count++
+		}
+		if count > scale {
+			scale = count
+		}
+	}
+	if denominator.Cmp(big.NewInt(1)) != 0 {
+		scale = decimalDivisionScale
+	}
+
+	numerator := new(big.Int).Mul(r.Num(), pow10(scale))
+	quotient, remainder := new(big.Int).QuoRem(numerator, r.Denom(), new(big.Int))
+	// 银行家舍入：余数超过一半时进位，正好一半时舍入到偶数
+	half := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(r.Denom())
+	if half > 0 || (half == 0 && quotient.Bit(0) == 1) {
+		quotient.Add(quotient, big.NewInt(int64(numerator.Sign())))
+	}
+	return &Decimal{unscaled: quotient, scale: scale}
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// rescale 返回把小数位数调整为 scale 后的 unscaled 值，scale 小于当前位数时截断
+func (d *Decimal) rescale(scale int) *big.Int {
+	if scale >= d.scale {
+		return new(big.Int).Mul(d.unscaled, pow10(scale-d.scale))
+	}
+	return new(big.Int).Quo(d.unscaled, pow10(d.scale-scale))
+}
+
+// Rat 返回小数对应的有理数
+func (d *Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+func (d *Decimal) isInt() bool {
+	return d.Rat().IsInt()
+}
+
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.unscaled).String()
+	sign := ""
+	if d.unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale == 0 {
+		return sign + digits
+	}
+	if len(digits) <= d.scale {
+		digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+	}
+	point := len(digits) - d.scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+func (d *Decimal) neg() *Decimal {
+	return &Decimal{unscaled: new(big.Int).Neg(d.unscaled), scale: d.scale}
+}
+
+func (d *Decimal) cmp(other *Decimal) int {
+	scale := maxInt(d.scale, other.scale)
+	return d.rescale(scale).Cmp(other.rescale(scale))
+}
+
+func (d *Decimal) add(other *Decimal) *Decimal {
+	scale := maxInt(d.scale, other.scale)
+	return &Decimal{unscaled: new(big.Int).Add(d.rescale(scale), other.rescale(scale)), scale: scale}
+}
+
+func (d *Decimal) sub(other *Decimal) *Decimal {
+	return d.add(other.neg())
+}
+
+func (d *Decimal) mul(other *Decimal) *Decimal {
+	return &Decimal{unscaled: new(big.Int).Mul(d.unscaled, other.unscaled), scale: d.scale + other.scale}
+}
+
+// quo 计算 d / other，调用方保证 other 不为 0
+func (d *Decimal) quo(other *Decimal) *Decimal {
+	return decimalFromRat(new(big.Rat).Quo(d.Rat(), other.Rat()))
+}
+
+// truncQuo 计算向零取整的商
+func (d *Decimal) truncQuo(other *Decimal) *Decimal {
+	scale := maxInt(d.scale, other.scale)
+	return &Decimal{unscaled: new(big.Int).Quo(d.rescale(scale), other.rescale(scale)), scale: 0}
+}
+
+// rem 计算余数，符号和被除数相同
+func (d *Decimal) rem(other *Decimal) *Decimal {
+	return d.sub(other.mul(d.truncQuo(other)))
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
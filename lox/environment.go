@@ -3,6 +3,8 @@ package lox
 type Environment struct {
 	values    map[string]interface{}
 	enclosing *Environment
+	// 内置环境被主脚本和所有模块共享，脚本不能修改它，见 assign
+	readOnly bool
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
@@ -54,6 +56,11 @@ func (e *Environment) assign(name *Token, value interface{}) {
 	}
 
 	if e.enclosing != nil {
+		// 给内置变量赋值时在当前的全局环境中定义一个同名变量，不影响其他模块
+		if _, ok := e.enclosing.values[name.lexeme]; ok && e.enclosing.readOnly {
+			e.values[name.lexeme] = value
+			return
+		}
 		e.enclosing.assign(name, value)
 		return
 	}
@@ -0,0 +1,153 @@
+package lox
+
+import (
+	"fmt"
+	"github.com/gookit/slog"
+	"io"
+	"strings"
+)
+
+// staticError 是扫描、解析和变量解析阶段错误的公共部分
+type staticError struct {
+	File    string
+	Line    int
+	Column  int
+	Offset  int
+	Length  int
+	Lexeme  string
+	Message string
+	atEnd   bool
+	src     *Source
+}
+
+func (e *staticError) where() string {
+	if e.atEnd {
+		return " at end"
+	}
+	if e.Lexeme == "" {
+		return ""
+	}
+	return " at '" + e.Lexeme + "'"
+}
+
+func (e *staticError) Error() string {
+	return fmt.Sprintf("[line %d] Error%s: %s", e.Line, e.where(), e.Message)
+}
+
+func newStaticErrorToken(token *Token, message string) staticError {
+	return staticError{
+		File:    token.File(),
+		Line:    token.line,
+		Column:  token.column,
+		Offset:  token.offset,
+		Length:  token.length,
+		Lexeme:  token.lexeme,
+		Message: message,
+		atEnd:   token.tokenType == TokenType_EOF,
+		src:     token.src,
+	}
+}
+
+// Snippet 渲染出错的源码行，并在出错位置下面画出 ^ 标记
+func (e *staticError) Snippet() string {
+	return renderSnippet(e.src, e.Line, e.Column, e.Offset, e.Length)
+}
+
+// ScanError 是词法扫描阶段的错误，Lexeme 为出错位置的原始文本
+type ScanError struct {
+	staticError
+}
+
+func NewScanError(token *Token, message string) *ScanError {
+	e := &ScanError{
+		newStaticErrorToken(token, message),
+	}
+	return e
+}
+
+func (e *ScanError) Error() string {
+	// 扫描错误沿用原来的输出格式，不带 at '...'
+	return fmt.Sprintf("[line %d] Error: %s", e.Line, e.Message)
+}
+
+// ParseError 是语法分析阶段的错误
+type ParseError struct {
+	staticError
+}
+
+func NewParseError(token *Token, message string) *ParseError {
+	e := &ParseError{
+		newStaticErrorToken(token, message),
+	}
+	return e
+}
+
+// ResolveError 是变量解析阶段的错误
+type ResolveError struct {
+	staticError
+}
+
+func NewResolveError(token *Token, message string) *ResolveError {
+	e := &ResolveError{
+		newStaticErrorToken(token, message),
+	}
+	return e
+}
+
+// ErrorList 汇总一次运行中发现的全部静态错误
+type ErrorList []error
+
+func (l ErrorList) Error() string {
+	messages := make([]string, 0, len(l))
+	for _, err := range l {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+// ErrorReporter 接收 VM 发现的每一个错误，包括静态错误和 *RuntimeError
+type ErrorReporter interface {
+	ReportError(err error)
+}
+
+// SlogReporter 把错误写到 slog，这是命令行使用的默认输出
+type SlogReporter struct {
+}
+
+func (r *SlogReporter) ReportError(err error) {
+	if runtimeError, ok := err.(*RuntimeError); ok {
+		slog.Errorf("%s\n%s%s", err.Error(), runtimeError.Snippet(), strings.TrimRight(runtimeError.StackTrace(), "\n"))
+	} else {
+		slog.Errorf("<error>%s", strings.TrimRight(err.Error()+"\n"+snippet(err), "\n"))
+	}
+}
+
+// WriterReporter 把错误以纯文本逐行写到 Writer
+type WriterReporter struct {
+	Writer io.Writer
+}
+
+func (r *WriterReporter) ReportError(err error) {
+	fmt.Fprintln(r.Writer, err.Error())
+	fmt.Fprint(r.Writer, snippet(err))
+	if runtimeError, ok := err.(*RuntimeError); ok {
+		fmt.Fprint(r.Writer, runtimeError.StackTrace())
+	}
+}
+
+// snippet 返回错误对应的源码片段，错误不带位置信息时返回空串
+func snippet(err error) string {
+	if s, ok := err.(interface{ Snippet() string }); ok {
+		return s.Snippet()
+	}
+	return ""
+}
+
+// ErrorCollector 只收集错误不做输出，方便嵌入方自行处理诊断信息
+type ErrorCollector struct {
+	Errors []error
+}
+
+func (c *ErrorCollector) ReportError(err error) {
+	c.Errors = append(c.Errors, err)
+}
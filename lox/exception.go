@@ -0,0 +1,106 @@
+package lox
+
+import (
+	"lox_go/util"
+)
+
+// prelude 是每个解释器创建时先执行的 Lox 代码。
+// Error 是所有错误的基类，被捕获的运行时错误会变成它的实例，脚本也可以继承它定义自己的错误。
+const prelude = `
+class Error {
+  init(message) {
+    this.message = message;
+    this.line = nil;
+    this.stack = nil;
+  }
+}
+`
+
+// runPrelude 在内置环境中执行 prelude，它不会出错，所以这里用一个不报告错误的 VM
+func (i *Interpreter) runPrelude() {
+	vm := &VM{interpreter: i}
+	tokens := NewScanner(vm, &Source{Name: "<prelude>", Text: prelude}).scanTokens()
+	statements := NewParse(vm, tokens).parse()
+	NewResolver(vm).resolveStmt(statements)
+	i.interpret(statements)
+
+	errorClass, _ := i.builtins.values["Error"].(*LoxClass)
+	i.errorClass = errorClass
+}
+
+func (i *Interpreter) VisitThrowStmt(stmt *ThrowStmt) {
+	value := i.evaluate(stmt.value)
+	trace := i.stackTrace(stmt.keyword)
+
+	message := util.GetInterfaceToString(value)
+	if instance, ok := i.errorInstance(value); ok {
+		// 第一次抛出时记录位置，重新抛出捕获到的错误时保留原来的位置
+		if instance.fields["line"] == nil {
+			instance.fields["line"] = int64(stmt.keyword.line)
+			instance.fields["stack"] = formatStackTrace(trace)
+		}
+		message = util.GetInterfaceToString(instance.fields["message"])
+	}
+
+	err := NewRuntimeError(stmt.keyword, message)
+	err.Trace = trace
+	err.Value = value
+	err.thrown = true
+	panic(err)
+}
+
+func (i *Interpreter) VisitTryStmt(stmt *TryStmt) {
+	if stmt.finallyBody != nil {
+		// finally 在 try、catch 正常结束或者因为 return、break、错误离开时都会执行；
+		// 如果 finally 自己又 return 或抛出错误，会取代原来的
+		environment := NewEnvironment(i.env)
+		defer i.executeBlock(stmt.finallyBody, environment)
+	}
+	i.executeTryCatch(stmt)
+}
+
+func (i *Interpreter) executeTryCatch(stmt *TryStmt) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(*RuntimeError)
+			if !ok || stmt.catchName == nil || !err.catchable() {
+				panic(r)
+			}
+			environment := NewEnvironment(i.env)
+			environment.define(stmt.catchName.lexeme, i.errorValue(err))
+			i.executeBlock(stmt.catchBody, environment)
+		}
+	}()
+
+	i.executeBlock(stmt.body, NewEnvironment(i.env))
+}
+
+// errorValue 返回 catch 拿到的值：throw 抛出的值原样返回，解释器内部的错误包装成 Error 实例
+func (i *Interpreter) errorValue(err *RuntimeError) interface{} {
+	if err.thrown {
+		return err.Value
+	}
+	if err.Trace == nil {
+		err.Trace = i.stackTrace(err.Token)
+	}
+
+	instance := NewLoxInstance(i.errorClass)
+	instance.fields["message"] = err.Message
+	instance.fields["line"] = int64(err.Token.line)
+	instance.fields["stack"] = err.StackTrace()
+	return instance
+}
+
+// errorInstance 判断 value 是不是 Error 或者它的子类的实例
+func (i *Interpreter) errorInstance(value interface{}) (*LoxInstance, bool) {
+	instance, ok := value.(*LoxInstance)
+	if !ok {
+		return nil, false
+	}
+	for class := instance.class; class != nil; class = class.superclass {
+		if class == i.errorClass {
+			return instance, true
+		}
+	}
+	return nil, false
+}
@@ -46,6 +46,19 @@ func NewCallExpr(callee Expr, paren *Token, arguments []Expr)*CallExpr{
 	return c
 }
 
+type FunctionExpr struct{
+	keyword *Token
+	declaration *FunctionStmt
+}
+
+func NewFunctionExpr(keyword *Token, declaration *FunctionStmt)*FunctionExpr{
+	f := &FunctionExpr{
+		keyword: keyword,
+		declaration: declaration,
+	}
+	return f
+}
+
 type GetExpr struct{
 	object Expr
 	name *Token
@@ -70,6 +83,51 @@ func NewGroupingExpr(expression Expr)*GroupingExpr{
 	return g
 }
 
+type IndexExpr struct{
+	object Expr
+	bracket *Token
+	index Expr
+}
+
+func NewIndexExpr(object Expr, bracket *Token, index Expr)*IndexExpr{
+	i := &IndexExpr{
+		object: object,
+		bracket: bracket,
+		index: index,
+	}
+	return i
+}
+
+type IndexSetExpr struct{
+	object Expr
+	bracket *Token
+	index Expr
+	value Expr
+}
+
+func NewIndexSetExpr(object Expr, bracket *Token, index Expr, value Expr)*IndexSetExpr{
+	i := &IndexSetExpr{
+		object: object,
+		bracket: bracket,
+		index: index,
+		value: value,
+	}
+	return i
+}
+
+type ListExpr struct{
+	bracket *Token
+	elements []Expr
+}
+
+func NewListExpr(bracket *Token, elements []Expr)*ListExpr{
+	l := &ListExpr{
+		bracket: bracket,
+		elements: elements,
+	}
+	return l
+}
+
 type LiteralExpr struct{
 	value interface{}
 }
@@ -96,6 +154,21 @@ func NewLogicalExpr(left Expr, operator *Token, right Expr)*LogicalExpr{
 	return l
 }
 
+type MapExpr struct{
+	brace *Token
+	keys []Expr
+	values []Expr
+}
+
+func NewMapExpr(brace *Token, keys []Expr, values []Expr)*MapExpr{
+	m := &MapExpr{
+		brace: brace,
+		keys: keys,
+		values: values,
+	}
+	return m
+}
+
 type SetExpr struct{
 	object Expr
 	name *Token
@@ -163,10 +236,15 @@ type ExprVisitor interface{
 	VisitAssignExpr(assignexpr *AssignExpr)
 	VisitBinaryExpr(binaryexpr *BinaryExpr)
 	VisitCallExpr(callexpr *CallExpr)
+	VisitFunctionExpr(functionexpr *FunctionExpr)
 	VisitGetExpr(getexpr *GetExpr)
 	VisitGroupingExpr(groupingexpr *GroupingExpr)
+	VisitIndexExpr(indexexpr *IndexExpr)
+	VisitIndexSetExpr(indexsetexpr *IndexSetExpr)
+	VisitListExpr(listexpr *ListExpr)
 	VisitLiteralExpr(literalexpr *LiteralExpr)
 	VisitLogicalExpr(logicalexpr *LogicalExpr)
+	VisitMapExpr(mapexpr *MapExpr)
 	VisitSetExpr(setexpr *SetExpr)
 	VisitSuperExpr(superexpr *SuperExpr)
 	VisitThisExpr(thisexpr *ThisExpr)
@@ -182,14 +260,24 @@ func VisitorExpr(v ExprVisitor,e Expr){
 		v.VisitBinaryExpr(e.(*BinaryExpr))
 	case *CallExpr:
 		v.VisitCallExpr(e.(*CallExpr))
+	case *FunctionExpr:
+		v.VisitFunctionExpr(e.(*FunctionExpr))
 	case *GetExpr:
 		v.VisitGetExpr(e.(*GetExpr))
 	case *GroupingExpr:
 		v.VisitGroupingExpr(e.(*GroupingExpr))
+	case *IndexExpr:
+		v.VisitIndexExpr(e.(*IndexExpr))
+	case *IndexSetExpr:
+		v.VisitIndexSetExpr(e.(*IndexSetExpr))
+	case *ListExpr:
+		v.VisitListExpr(e.(*ListExpr))
 	case *LiteralExpr:
 		v.VisitLiteralExpr(e.(*LiteralExpr))
 	case *LogicalExpr:
 		v.VisitLogicalExpr(e.(*LogicalExpr))
+	case *MapExpr:
+		v.VisitMapExpr(e.(*MapExpr))
 	case *SetExpr:
 		v.VisitSetExpr(e.(*SetExpr))
 	case *SuperExpr:
@@ -207,10 +295,15 @@ type ExprVisitorWithVal[T any] interface{
 	VisitAssignExpr(assignexpr *AssignExpr) T
 	VisitBinaryExpr(binaryexpr *BinaryExpr) T
 	VisitCallExpr(callexpr *CallExpr) T
+	VisitFunctionExpr(functionexpr *FunctionExpr) T
 	VisitGetExpr(getexpr *GetExpr) T
 	VisitGroupingExpr(groupingexpr *GroupingExpr) T
+	VisitIndexExpr(indexexpr *IndexExpr) T
+	VisitIndexSetExpr(indexsetexpr *IndexSetExpr) T
+	VisitListExpr(listexpr *ListExpr) T
 	VisitLiteralExpr(literalexpr *LiteralExpr) T
 	VisitLogicalExpr(logicalexpr *LogicalExpr) T
+	VisitMapExpr(mapexpr *MapExpr) T
 	VisitSetExpr(setexpr *SetExpr) T
 	VisitSuperExpr(superexpr *SuperExpr) T
 	VisitThisExpr(thisexpr *ThisExpr) T
@@ -226,14 +319,24 @@ func VisitorExprWithVal[T any](v ExprVisitorWithVal[T],e Expr) T{
 		return v.VisitBinaryExpr(e.(*BinaryExpr))
 	case *CallExpr:
 		return v.VisitCallExpr(e.(*CallExpr))
+	case *FunctionExpr:
+		return v.VisitFunctionExpr(e.(*FunctionExpr))
 	case *GetExpr:
 		return v.VisitGetExpr(e.(*GetExpr))
 	case *GroupingExpr:
 		return v.VisitGroupingExpr(e.(*GroupingExpr))
+	case *IndexExpr:
+		return v.VisitIndexExpr(e.(*IndexExpr))
+	case *IndexSetExpr:
+		return v.VisitIndexSetExpr(e.(*IndexSetExpr))
+	case *ListExpr:
+		return v.VisitListExpr(e.(*ListExpr))
 	case *LiteralExpr:
 		return v.VisitLiteralExpr(e.(*LiteralExpr))
 	case *LogicalExpr:
 		return v.VisitLogicalExpr(e.(*LogicalExpr))
+	case *MapExpr:
+		return v.VisitMapExpr(e.(*MapExpr))
 	case *SetExpr:
 		return v.VisitSetExpr(e.(*SetExpr))
 	case *SuperExpr:
@@ -1,15 +1,20 @@
 package lox
 
 type LoxFunction struct {
-	declaration   *FunctionStmt
-	closure       *Environment
+	declaration *FunctionStmt
+	closure     *Environment
+	// 定义函数时的全局环境，也就是函数所在模块的全局环境，函数中未解析的变量在这里查找
+	globals       *Environment
 	isInitializer bool
+	// 方法所属的类名，普通函数为空
+	className string
 }
 
-func NewLoxFunction(declaration *FunctionStmt, closure *Environment, isInitializer bool) *LoxFunction {
+func NewLoxFunction(declaration *FunctionStmt, closure *Environment, globals *Environment, isInitializer bool) *LoxFunction {
 	f := &LoxFunction{
 		declaration:   declaration,
 		closure:       closure,
+		globals:       globals,
 		isInitializer: isInitializer,
 	}
 	return f
@@ -25,6 +30,13 @@ func (l *LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (r
 		environment.define(p.lexeme, arguments[i])
 	}
 
+	// 从其他模块调用时切换到函数所在模块的全局环境
+	previous := interpreter.globals
+	interpreter.globals = l.globals
+	defer func() {
+		interpreter.globals = previous
+	}()
+
 	defer func() {
 		if r := recover(); r != nil {
 			if ret, ok := r.(*Return); ok {
@@ -48,11 +60,21 @@ func (l *LoxFunction) Call(interpreter *Interpreter, arguments []interface{}) (r
 	return
 }
 func (l *LoxFunction) String() string {
-	return "<fn " + l.declaration.name.lexeme + ">"
+	return "<fn " + l.name() + ">"
+}
+
+// name 返回函数名，匿名函数返回 anonymous
+func (l *LoxFunction) name() string {
+	if l.declaration.name == nil {
+		return "anonymous"
+	}
+	return l.declaration.name.lexeme
 }
 
 func (l *LoxFunction) Bind(instance *LoxInstance) *LoxFunction {
 	environment := NewEnvironment(l.closure)
 	environment.define("this", instance)
-	return NewLoxFunction(l.declaration, environment, l.isInitializer)
+	bound := NewLoxFunction(l.declaration, environment, l.globals, l.isInitializer)
+	bound.className = l.className
+	return bound
 }
@@ -3,6 +3,8 @@ package lox
 type LoxInstance struct {
 	class  *LoxClass
 	fields map[string]interface{}
+	// 继承原生类时背后的 Go 对象
+	native *NativeInstance
 }
 
 func NewLoxInstance(class *LoxClass) *LoxInstance {
@@ -28,9 +30,19 @@ func (l *LoxInstance) Get(name *Token) interface{} {
 		return method.Bind(l)
 	}
 
+	if l.native != nil {
+		if value, ok := l.native.get(name.lexeme); ok {
+			return value
+		}
+	}
+
 	panic(NewRuntimeError(name, "Undefined property '"+name.lexeme+"'."))
 }
 
 func (l *LoxInstance) Set(name *Token, value interface{}) {
+	if l.native != nil && l.native.hasField(name.lexeme) {
+		l.native.Set(name, value)
+		return
+	}
 	l.fields[name.lexeme] = value
 }
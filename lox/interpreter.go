@@ -1,34 +1,81 @@
 package lox
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"github.com/gookit/slog"
+	"io"
 	"lox_go/util"
-	"strconv"
+	"time"
 )
 
 type Interpreter struct {
 	env     *Environment
 	globals *Environment
 	locals  map[Expr]int
+
+	// 内置函数、prelude 和宿主注册的原生函数、原生类，是主脚本和所有模块的全局环境的外层
+	builtins *Environment
+	// 模块加载器，由 VM 设置，见 module.go
+	loader *moduleLoader
+	// DefineModule 注册的原生模块
+	nativeModules map[string]*LoxModule
+
+	// 执行预算，见 budget.go
+	ctx      context.Context
+	maxSteps int
+	timeout  time.Duration
+	steps    int
+	deadline time.Time
+	// 宿主进入脚本的嵌套层数，见 enter
+	entered int
+
+	// 函数调用深度限制，<= 0 表示不限制
+	maxCallDepth int
+	frames       []callFrame
+
+	// 输入输出，nil 表示使用进程的标准输入输出，见 stdio.go
+	stdout io.Writer
+	stderr io.Writer
+	stdin  *bufio.Reader
+
+	// prelude 中定义的 Error 类，捕获到的运行时错误会包装成它的实例，见 exception.go
+	errorClass *LoxClass
 }
 
+// 默认的最大调用深度，足够普通递归使用，又远低于 Go 协程栈溢出的深度
+const defaultMaxCallDepth = 10000
+
 func NewInterpreter() *Interpreter {
 	i := &Interpreter{}
-	i.globals = NewEnvironment(nil)
+	i.builtins = NewEnvironment(nil)
+	i.globals = i.builtins
 	i.env = i.globals
 	i.locals = make(map[Expr]int)
+	i.maxCallDepth = defaultMaxCallDepth
 
-	i.globals.define("clock", NewCallableClock())
+	i.builtins.define("clock", NewCallableClock())
+	i.defineNumberNatives()
+	i.defineMathModule()
+	_ = i.DefineNative("len", nativeLen)
+	i.runPrelude()
+
+	i.builtins.readOnly = true
+	i.globals = NewEnvironment(i.builtins)
+	i.env = i.globals
 	return i
 }
 
-func (i *Interpreter) interpret(statements []Stmt) {
+func (i *Interpreter) interpret(statements []Stmt) (runtimeError *RuntimeError) {
 	defer func() {
 		if err := recover(); err != nil {
 			switch v := err.(type) {
 			case *RuntimeError:
-				reportRuntimeError(v)
+				if v.Trace == nil {
+					v.Trace = i.stackTrace(v.Token)
+				}
+				runtimeError = v
 			case *Return:
 				slog.Errorf("Unexpected return: %v", v)
 			default:
@@ -40,9 +87,13 @@ func (i *Interpreter) interpret(statements []Stmt) {
 	for _, statement := range statements {
 		i.execute(statement)
 	}
+	return nil
 }
 
 func (i *Interpreter) execute(stmt Stmt) {
+	if message, err := i.step(); err != nil {
+		panic(newBudgetError(stmtToken(stmt), message, err))
+	}
 	VisitorStmt(i, stmt)
 }
 
@@ -68,9 +119,14 @@ func (i *Interpreter) VisitBlockStmt(stmt *BlockStmt) {
 
 func (i *Interpreter) VisitClassStmt(stmt *ClassStmt) {
 	var superclass *LoxClass = nil
+	var nativeSuperclass *NativeClass = nil
 	if stmt.superclass != nil {
-		superclass = i.evaluate(stmt.superclass).(*LoxClass)
-		if superclass == nil {
+		switch v := i.evaluate(stmt.superclass).(type) {
+		case *LoxClass:
+			superclass = v
+		case *NativeClass:
+			nativeSuperclass = v
+		default:
 			panic(NewRuntimeError(stmt.superclass.name, "Superclass must be a class."))
 		}
 	}
@@ -78,15 +134,23 @@ func (i *Interpreter) VisitClassStmt(stmt *ClassStmt) {
 	i.env.define(stmt.name.lexeme, nil)
 	if stmt.superclass != nil {
 		i.env = NewEnvironment(i.env)
-		i.env.define("super", superclass)
+		if superclass != nil {
+			i.env.define("super", superclass)
+		} else {
+			i.env.define("super", nativeSuperclass)
+		}
 	}
 	methods := make(map[string]*LoxFunction)
 	for _, method := range stmt.methods {
-		function := NewLoxFunction(method, i.env, method.name.lexeme == "init")
+		function := NewLoxFunction(method, i.env, i.globals, method.name.lexeme == "init")
+		function.className = stmt.name.lexeme
 		methods[method.name.lexeme] = function
 	}
 	klass := NewLoxClass(stmt.name.lexeme, superclass, methods)
-	if superclass != nil {
+	if nativeSuperclass != nil {
+		klass.native = nativeSuperclass
+	}
+	if stmt.superclass != nil {
 		i.env = i.env.enclosing
 	}
 	i.env.assign(stmt.name, klass)
@@ -97,7 +161,7 @@ func (i *Interpreter) VisitExpressionStmt(stmt *ExpressionStmt) {
 }
 
 func (i *Interpreter) VisitFunctionStmt(stmt *FunctionStmt) {
-	function := NewLoxFunction(stmt, i.env, false)
+	function := NewLoxFunction(stmt, i.env, i.globals, false)
 	i.env.define(stmt.name.lexeme, function)
 }
 
@@ -111,7 +175,7 @@ func (i *Interpreter) VisitIfStmt(stmt *IfStmt) {
 
 func (i *Interpreter) VisitPrintStmt(stmt *PrintStmt) {
 	value := i.evaluate(stmt.expression)
-	fmt.Print(util.GetInterfaceToString(value))
+	fmt.Fprint(i.Stdout(), util.GetInterfaceToString(value))
 }
 
 func (i *Interpreter) VisitReturnStmt(stmt *ReturnStmt) {
@@ -132,8 +196,45 @@ func (i *Interpreter) VisitVarStmt(stmt *VarStmt) {
 
 func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) {
 	for i.isTruthy(i.evaluate(stmt.condition)) {
-		i.execute(stmt.body)
+		if i.executeLoopBody(stmt) {
+			break
+		}
+		if stmt.increment != nil {
+			i.evaluate(stmt.increment)
+		}
+	}
+}
+
+// executeLoopBody 执行一次循环体，遇到属于这个循环的 break 时返回 true。
+// 属于外层循环的 break、continue 继续向上传递。
+func (i *Interpreter) executeLoopBody(stmt *WhileStmt) (broken bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			control, ok := r.(*LoopControl)
+			if !ok || (control.label != "" && (stmt.label == nil || control.label != stmt.label.lexeme)) {
+				panic(r)
+			}
+			broken = !control.isContinue
+		}
+	}()
+
+	i.execute(stmt.body)
+	return false
+}
+
+func (i *Interpreter) VisitBreakStmt(stmt *BreakStmt) {
+	panic(NewLoopControl(labelName(stmt.label), false))
+}
+
+func (i *Interpreter) VisitContinueStmt(stmt *ContinueStmt) {
+	panic(NewLoopControl(labelName(stmt.label), true))
+}
+
+func labelName(label *Token) string {
+	if label == nil {
+		return ""
 	}
+	return label.lexeme
 }
 
 func (i *Interpreter) VisitAssignExpr(expr *AssignExpr) interface{} {
@@ -175,29 +276,51 @@ func (i *Interpreter) VisitLogicalExpr(expr *LogicalExpr) interface{} {
 func (i *Interpreter) VisitSetExpr(expr *SetExpr) interface{} {
 	object := i.evaluate(expr.object)
 
-	instance, ok := object.(*LoxInstance)
-	if !ok {
-		panic(NewRuntimeError(expr.name, "Only instances have fields."))
+	switch instance := object.(type) {
+	case *LoxInstance:
+		value := i.evaluate(expr.value)
+		instance.Set(expr.name, value)
+		return value
+	case *NativeInstance:
+		value := i.evaluate(expr.value)
+		instance.Set(expr.name, value)
+		return value
 	}
-
-	value := i.evaluate(expr.value)
-	instance.Set(expr.name, value)
-	return value
+	panic(NewRuntimeError(expr.name, "Only instances have fields."))
 }
 
 func (i *Interpreter) VisitSuperExpr(expr *SuperExpr) interface{} {
 	distance := i.locals[expr]
 
-	superclass := i.env.getAt(distance, "super").(*LoxClass)
 	object := i.env.getAt(distance-1, "this").(*LoxInstance)
+	if nativeSuperclass, ok := i.env.getAt(distance, "super").(*NativeClass); ok {
+		return nativeSuperMethod(nativeSuperclass, object, expr.method)
+	}
+
+	superclass := i.env.getAt(distance, "super").(*LoxClass)
 	method := superclass.FindMethod(expr.method.lexeme)
 	if method == nil {
+		// Lox 父类自己没有这个方法时，继续在继承链顶端的原生类中查找
+		if superclass.native != nil {
+			return nativeSuperMethod(superclass.native, object, expr.method)
+		}
 		panic(NewRuntimeError(expr.method, "Undefined property '"+expr.method.lexeme+"'."))
 	}
 	return method.Bind(object)
 
 }
 
+// nativeSuperMethod 查找原生父类 class 上的 super.name
+func nativeSuperMethod(class *NativeClass, object *LoxInstance, name *Token) interface{} {
+	if name.lexeme == "init" {
+		return &nativeSuperInit{class: class, instance: object}
+	}
+	if method, ok := object.native.get(name.lexeme); ok {
+		return method
+	}
+	panic(NewRuntimeError(name, "Undefined property '"+name.lexeme+"'."))
+}
+
 func (i *Interpreter) VisitThisExpr(expr *ThisExpr) interface{} {
 	return i.lookUpVariable(expr.keyword, expr)
 }
@@ -211,8 +334,7 @@ func (i *Interpreter) VisitUnaryExpr(expr *UnaryExpr) interface{} {
 
 	switch expr.operator.tokenType {
 	case TokenType_MINUS:
-		i.checkNumberOperand(expr.operator, right)
-		return -(right.(float64))
+		return i.negate(expr.operator, right)
 	case TokenType_BANG:
 		return !i.isTruthy(right)
 	}
@@ -238,48 +360,26 @@ func (i *Interpreter) VisitBinaryExpr(expr *BinaryExpr) interface{} {
 	right := i.evaluate(expr.right)
 
 	switch expr.operator.tokenType {
-	case TokenType_MINUS:
-		i.checkNumberOperands(expr.operator, left, right)
-		return left.(float64) - right.(float64)
 	case TokenType_PLUS:
 		// 加分特殊，不只是数值加法，还要考虑字符串连接
-		f1, fok1 := left.(float64)
-		f2, fok2 := right.(float64)
-		if fok1 && fok2 {
-			return f1 + f2
-		}
 		s1, sok1 := left.(string)
 		s2, sok2 := right.(string)
+		if sok1 && isNumber(right) {
+			s2, sok2 = util.GetInterfaceToString(right), true
+		} else if sok2 && isNumber(left) {
+			s1, sok1 = util.GetInterfaceToString(left), true
+		}
 		if sok1 && sok2 {
+			checkStringLength(expr.operator, int64(len(s1))+int64(len(s2)), "concatenation")
 			return s1 + s2
 		}
-		if sok1 && fok2 {
-			key2 := strconv.FormatFloat(f2, 'f', -1, 64)
-			return s1 + key2
-		}
-		if sok2 && fok1 {
-			key1 := strconv.FormatFloat(f1, 'f', -1, 64)
-			return key1 + s2
+		if !isNumber(left) || !isNumber(right) {
+			panic(NewRuntimeError(expr.operator, "Operands must be two numbers or strings."))
 		}
-		panic(NewRuntimeError(expr.operator, "Operands must be two numbers or strings."))
-	case TokenType_SLASH:
-		i.checkNumberOperands(expr.operator, left, right)
-		return left.(float64) / right.(float64)
-	case TokenType_STAR:
-		i.checkNumberOperands(expr.operator, left, right)
-		return left.(float64) * right.(float64)
-	case TokenType_GREATER:
-		i.checkNumberOperands(expr.operator, left, right)
-		return left.(float64) > right.(float64)
-	case TokenType_GREATER_EQUAL:
-		i.checkNumberOperands(expr.operator, left, right)
-		return left.(float64) >= right.(float64)
-	case TokenType_LESS:
-		i.checkNumberOperands(expr.operator, left, right)
-		return left.(float64) < right.(float64)
-	case TokenType_LESS_EQUAL:
-		i.checkNumberOperands(expr.operator, left, right)
-		return left.(float64) <= right.(float64)
+		return i.arithmetic(expr.operator, left, right)
+	case TokenType_MINUS, TokenType_SLASH, TokenType_STAR, TokenType_TILDE_SLASH, TokenType_PERCENT,
+		TokenType_GREATER, TokenType_GREATER_EQUAL, TokenType_LESS, TokenType_LESS_EQUAL:
+		return i.arithmetic(expr.operator, left, right)
 	case TokenType_BANG_EQUAL:
 		return !i.isEqual(left, right)
 	case TokenType_EQUAL_EQUAL:
@@ -296,25 +396,103 @@ func (i *Interpreter) VisitCallExpr(expr *CallExpr) interface{} {
 		arguments = append(arguments, i.evaluate(argument))
 	}
 
+	return i.call(callee, expr.paren, arguments)
+}
+
+// call 调用 callee，参数错误都报告在 paren 处
+func (i *Interpreter) call(callee interface{}, paren *Token, arguments []interface{}) interface{} {
 	function, ok := callee.(LoxCallable)
 	if !ok {
-		panic(NewRuntimeError(expr.paren, "Can only call functions and classes."))
+		panic(NewRuntimeError(paren, "Can only call functions and classes."))
 	}
-	// 新增部分开始
-	if len(arguments) != function.Arity() {
-		panic(NewRuntimeError(expr.paren, fmt.Sprintf("Expected %d arguments but got %d.", function.Arity(), len(arguments))))
+	if message, err := i.step(); err != nil {
+		panic(newBudgetError(paren, message, err))
 	}
 
+	i.pushFrame(function, paren)
+	defer i.popFrame()
+
+	if callable, ok := function.(callSiteCallable); ok {
+		return callable.callAt(i, paren, arguments)
+	}
+	// 新增部分开始
+	checkArity(paren, function.Arity(), len(arguments))
+
 	return function.Call(i, arguments)
 }
 
 func (i *Interpreter) VisitGetExpr(expr *GetExpr) interface{} {
 	object := i.evaluate(expr.object)
-	instance, ok := object.(*LoxInstance)
-	if !ok {
-		panic(NewRuntimeError(expr.name, "Only instances have properties."))
+	switch instance := object.(type) {
+	case *LoxInstance:
+		return instance.Get(expr.name)
+	case *NativeInstance:
+		return instance.Get(expr.name)
+	case *LoxList:
+		return instance.Get(expr.name)
+	case *LoxMap:
+		return instance.Get(expr.name)
+	case *LoxModule:
+		return instance.Get(expr.name)
+	case string:
+		return stringMethod(instance, expr.name)
+	}
+	panic(NewRuntimeError(expr.name, "Only instances have properties."))
+}
+
+func (i *Interpreter) VisitFunctionExpr(expr *FunctionExpr) interface{} {
+	return NewLoxFunction(expr.declaration, i.env, i.globals, false)
+}
+
+func (i *Interpreter) VisitListExpr(expr *ListExpr) interface{} {
+	elements := make([]interface{}, 0, len(expr.elements))
+	for _, element := range expr.elements {
+		elements = append(elements, i.evaluate(element))
+	}
+	return NewLoxList(elements)
+}
+
+func (i *Interpreter) VisitMapExpr(expr *MapExpr) interface{} {
+	m := NewLoxMap()
+	for index := range expr.keys {
+		key := i.evaluate(expr.keys[index])
+		value := i.evaluate(expr.values[index])
+		m.set(expr.brace, key, value)
+	}
+	return m
+}
+
+func (i *Interpreter) VisitIndexExpr(expr *IndexExpr) interface{} {
+	object := i.evaluate(expr.object)
+	index := i.evaluate(expr.index)
+
+	switch container := object.(type) {
+	case *LoxList:
+		return container.get(expr.bracket, index)
+	case *LoxMap:
+		return container.get(expr.bracket, index)
+	case string:
+		return stringGet(expr.bracket, container, index)
 	}
-	return instance.Get(expr.name)
+	panic(NewRuntimeError(expr.bracket, "Only lists, maps and strings can be indexed."))
+}
+
+func (i *Interpreter) VisitIndexSetExpr(expr *IndexSetExpr) interface{} {
+	object := i.evaluate(expr.object)
+	index := i.evaluate(expr.index)
+	value := i.evaluate(expr.value)
+
+	switch container := object.(type) {
+	case *LoxList:
+		container.set(expr.bracket, index, value)
+		return value
+	case *LoxMap:
+		container.set(expr.bracket, index, value)
+		return value
+	case string:
+		panic(NewRuntimeError(expr.bracket, "Strings are immutable."))
+	}
+	panic(NewRuntimeError(expr.bracket, "Only lists and maps can be assigned by index."))
 }
 
 func (i *Interpreter) isTruthy(obj interface{}) bool {
@@ -335,22 +513,8 @@ func (i *Interpreter) isEqual(a interface{}, b interface{}) bool {
 	if a == nil {
 		return false
 	}
-	return a == b
-}
-
-func (i *Interpreter) checkNumberOperand(operator *Token, operand interface{}) {
-	_, ok := operand.(float64)
-	if ok {
-		return
-	}
-	panic(NewRuntimeError(operator, "Operand must be a number."))
-}
-
-func (i *Interpreter) checkNumberOperands(operator *Token, left interface{}, right interface{}) {
-	_, ok1 := left.(float64)
-	_, ok2 := right.(float64)
-	if ok1 && ok2 {
-		return
+	if isNumber(a) && isNumber(b) {
+		return numbersEqual(a, b)
 	}
-	panic(NewRuntimeError(operator, "Operands must be a numbers."))
+	return a == b
 }
@@ -0,0 +1,203 @@
+package lox
+
+import (
+	"fmt"
+	"lox_go/util"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoxList 是 Lox 的列表，和实例一样按引用传递
+type LoxList struct {
+	elements []interface{}
+}
+
+func NewLoxList(elements []interface{}) *LoxList {
+	l := &LoxList{
+		elements: elements,
+	}
+	return l
+}
+
+// Elements 返回列表的元素，宿主可以直接读写
+func (l *LoxList) Elements() []interface{} {
+	return l.elements
+}
+
+func (l *LoxList) String() string {
+	return l.format(map[interface{}]bool{})
+}
+
+func (l *LoxList) format(seen map[interface{}]bool) string {
+	if seen[l] {
+		return "[...]"
+	}
+	seen[l] = true
+	defer delete(seen, l)
+
+	parts := make([]string, 0, len(l.elements))
+	for _, element := range l.elements {
+		parts = append(parts, formatElement(element, seen))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// formatElement 格式化容器里的元素，字符串带引号；seen 记录正在格式化的容器，避免自己包含自己时无限递归
+func formatElement(value interface{}, seen map[interface{}]bool) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return strconv.Quote(v)
+	case *LoxList:
+		return v.format(seen)
+	case *LoxMap:
+		return v.format(seen)
+	}
+	return util.GetInterfaceToString(value)
+}
+
+// index 检查下标并转换成 int，allowEnd 为 true 时允许下标等于长度（用于 insert、slice）
+func (l *LoxList) index(token *Token, value interface{}, allowEnd bool) int {
+	kind := numberKind(value)
+	n, ok := toInt(value)
+	if (kind != kindInt && kind != kindBigInt) || !ok {
+		panic(NewRuntimeError(token, "List index must be an integer."))
+	}
+	limit := int64(len(l.elements))
+	if allowEnd {
+		limit++
+	}
+	if n < 0 || n >= limit {
+		panic(NewRuntimeError(token, fmt.Sprintf("List index %d is out of range for length %d.", n, len(l.elements))))
+	}
+	return int(n)
+}
+
+func (l *LoxList) get(token *Token, index interface{}) interface{} {
+	return l.elements[l.index(token, index, false)]
+}
+
+func (l *LoxList) set(token *Token, index interface{}, value interface{}) {
+	l.elements[l.index(token, index, false)] = value
+}
+
+// Get 查找列表的方法
+func (l *LoxList) Get(name *Token) interface{} {
+	if method, ok := listMethods[name.lexeme]; ok {
+		return &builtinMethod{
+			class:    "list",
+			name:     name.lexeme,
+			minArity: method.minArity,
+			maxArity: method.maxArity,
+			fn: func(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+				return method.fn(l, i, paren, arguments)
+			},
+		}
+	}
+	panic(NewRuntimeError(name, "Undefined property '"+name.lexeme+"'."))
+}
+
+var listMethods = map[string]struct {
+	minArity int
+	maxArity int
+	fn       func(l *LoxList, i *Interpreter, paren *Token, arguments []interface{}) interface{}
+}{
+	"push":   {1, 1, (*LoxList).push},
+	"pop":    {0, 0, (*LoxList).pop},
+	"len":    {0, 0, (*LoxList).len},
+	"insert": {2, 2, (*LoxList).insert},
+	"slice":  {1, 2, (*LoxList).slice},
+	"sort":   {0, 1, (*LoxList).sort},
+}
+
+func (l *LoxList) push(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	l.elements = append(l.elements, arguments[0])
+	return nil
+}
+
+func (l *LoxList) pop(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	if len(l.elements) == 0 {
+		panic(NewRuntimeError(paren, "Can't pop from an empty list."))
+	}
+	last := l.elements[len(l.elements)-1]
+	l.elements = l.elements[:len(l.elements)-1]
+	return last
+}
+
+func (l *LoxList) len(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	return int64(len(l.elements))
+}
+
+func (l *LoxList) insert(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	index := l.index(paren, arguments[0], true)
+	l.elements = append(l.elements, nil)
+	copy(l.elements[index+1:], l.elements[index:])
+	l.elements[index] = arguments[1]
+	return nil
+}
+
+// slice 返回 [start, end) 之间元素组成的新列表，省略 end 时到列表末尾
+func (l *LoxList) slice(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	start := l.index(paren, arguments[0], true)
+	end := len(l.elements)
+	if len(arguments) > 1 {
+		end = l.index(paren, arguments[1], true)
+	}
+	if start > end {
+		panic(NewRuntimeError(paren, fmt.Sprintf("Slice start %d is after end %d.", start, end)))
+	}
+	elements := make([]interface{}, end-start)
+	copy(elements, l.elements[start:end])
+	return NewLoxList(elements)
+}
+
+// sort 原地稳定排序。不传比较函数时元素必须全是数值或全是字符串；
+// 比较函数 compare(a, b) 返回负数表示 a 排在 b 前面。
+func (l *LoxList) sort(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	var less func(a, b interface{}) bool
+	if len(arguments) == 1 {
+		compare := arguments[0]
+		less = func(a, b interface{}) bool {
+			result, ok := toFloat(i.call(compare, paren, []interface{}{a, b}))
+			if !ok {
+				panic(NewRuntimeError(paren, "Sort comparator must return a number."))
+			}
+			return result < 0
+		}
+	} else {
+		less = defaultLess(paren, l.elements)
+	}
+
+	// 比较函数可能修改列表，所以排序一个副本，排好后再放回去
+	elements := make([]interface{}, len(l.elements))
+	copy(elements, l.elements)
+	sort.SliceStable(elements, func(a, b int) bool {
+		return less(elements[a], elements[b])
+	})
+	l.elements = elements
+	return nil
+}
+
+// defaultLess 返回列表默认的比较方式
+func defaultLess(paren *Token, elements []interface{}) func(a, b interface{}) bool {
+	allNumbers, allStrings := true, true
+	for _, element := range elements {
+		_, isString := element.(string)
+		allNumbers = allNumbers && isNumber(element)
+		allStrings = allStrings && isString
+	}
+
+	switch {
+	case allNumbers:
+		return func(a, b interface{}) bool {
+			return compareNumbers(a, b) < 0
+		}
+	case allStrings:
+		return func(a, b interface{}) bool {
+			return a.(string) < b.(string)
+		}
+	}
+	panic(NewRuntimeError(paren, "Can only sort lists of numbers or strings without a comparator."))
+}
@@ -0,0 +1,16 @@
+package lox
+
+// LoopControl 由 break 和 continue 语句抛出，和 Return 一样沿着调用栈向上传递，
+// 直到被对应的循环接住。label 为空表示最内层的循环。
+type LoopControl struct {
+	label      string
+	isContinue bool
+}
+
+func NewLoopControl(label string, isContinue bool) *LoopControl {
+	c := &LoopControl{
+		label:      label,
+		isContinue: isContinue,
+	}
+	return c
+}
@@ -1,92 +1,14 @@
 package lox
 
-import (
-	"bufio"
-	"fmt"
-	"github.com/gookit/slog"
-	"io/ioutil"
-	"os"
-	"strings"
-)
-
-var hadError = false
-var hadRuntimeError = false
-
-var interpreter = NewInterpreter()
-
-func run(source string) {
-
-	scanner := NewScanner(source)
-	tokens := scanner.scanTokens()
-
-	parser := NewParse(tokens)
-	statements := parser.parse()
-	if hadError {
-		return
-	}
-
-	resolver := NewResolver(interpreter)
-	resolver.resolveStmt(statements)
-
-	interpreter.interpret(statements)
-}
-
-func Eval(code string) {
-	run(code)
-}
-
-func RunFile(filename string) {
-	code, err := ioutil.ReadFile(filename)
-	if err != nil {
-		slog.Infof("Error reading file: %s", filename)
-		return
-	}
-
-	run(string(code))
-
-	if hadError {
-		os.Exit(65)
-	}
-
-	if hadRuntimeError {
-		os.Exit(70)
-	}
-}
-
-func RunPrompt() {
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		fmt.Print("> ")
-		input, err := reader.ReadString('\n')
-		if err != nil {
-			slog.Errorf("input error:%v", err)
-			break
-		}
-		code := strings.TrimRight(input, "\n")
-		run(code)
-		hadError = false
-		fmt.Print("\n")
-	}
-}
-
-func reportError(line int, message string) {
-	report(line, "", message)
-}
-
-func reportErrorToken(token *Token, message string) {
-	if token.tokenType == TokenType_EOF {
-		report(token.line, " at end", message)
-	} else {
-		report(token.line, " at '"+token.lexeme+"'", message)
-	}
+// Eval 在一个全新的 VM 中执行代码
+func Eval(code string, options ...Option) error {
+	return NewVM(options...).Run(code)
 }
 
-func report(line int, where string, message string) {
-	slog.Errorf("<error>[line %d] Error%s: %s", line, where, message)
-	hadError = true
+func RunFile(filename string, options ...Option) error {
+	return NewVM(options...).RunFile(filename)
 }
 
-func reportRuntimeError(err *RuntimeError) {
-	slog.Errorf(err.Error())
-	hadRuntimeError = true
+func RunPrompt(options ...Option) error {
+	return NewVM(options...).RunPrompt()
 }
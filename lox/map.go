@@ -0,0 +1,180 @@
+package lox
+
+import (
+	"math"
+	"reflect"
+	"strings"
+)
+
+// LoxMap 是 Lox 的 map，按插入顺序保存键值对，和列表一样按引用传递。
+// 键按 Interpreter.isEqual 判断相等：数值按数学上的值比较，所以 1、1.0、1n、1.00m 是同一个键；
+// 字符串和布尔值按值比较；实例、列表、map、函数等按引用比较。
+type LoxMap struct {
+	entries []mapEntry
+	index   map[interface{}]int
+}
+
+type mapEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// numberKey 是无法用 int64 表示的数值的哈希键，内容为有理数的字符串形式，例如 "1/2"
+type numberKey string
+
+func NewLoxMap() *LoxMap {
+	m := &LoxMap{
+		index: make(map[interface{}]int),
+	}
+	return m
+}
+
+// hashKey 把键转换成 Go map 的键，保证 isEqual 相等的两个值得到同一个键
+func hashKey(token *Token, key interface{}) interface{} {
+	switch v := key.(type) {
+	case int64:
+		return v
+	case float64:
+		if math.IsNaN(v) {
+			panic(NewRuntimeError(token, "Map key can't be NaN."))
+		}
+		if math.IsInf(v, 0) {
+			return v
+		}
+	}
+	if isNumber(key) {
+		r := toRat(key)
+		if r.IsInt() && r.Num().IsInt64() {
+			return r.Num().Int64()
+		}
+		return numberKey(r.RatString())
+	}
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		panic(NewRuntimeError(token, "Map key must be hashable."))
+	}
+	return key
+}
+
+// Len 返回键值对的个数
+func (m *LoxMap) Len() int {
+	return len(m.entries)
+}
+
+// Keys 按插入顺序返回所有的键
+func (m *LoxMap) Keys() []interface{} {
+	keys := make([]interface{}, 0, len(m.entries))
+	for _, entry := range m.entries {
+		keys = append(keys, entry.key)
+	}
+	return keys
+}
+
+func (m *LoxMap) lookup(token *Token, key interface{}) (interface{}, bool) {
+	position, ok := m.index[hashKey(token, key)]
+	if !ok {
+		return nil, false
+	}
+	return m.entries[position].value, true
+}
+
+func (m *LoxMap) get(token *Token, key interface{}) interface{} {
+	value, ok := m.lookup(token, key)
+	if !ok {
+		panic(NewRuntimeError(token, "Undefined key "+formatElement(key, map[interface{}]bool{})+"."))
+	}
+	return value
+}
+
+// set 写入键值对，键已经存在时保留原来的位置和原来的键
+func (m *LoxMap) set(token *Token, key interface{}, value interface{}) {
+	hash := hashKey(token, key)
+	if position, ok := m.index[hash]; ok {
+		m.entries[position].value = value
+		return
+	}
+	m.index[hash] = len(m.entries)
+	m.entries = append(m.entries, mapEntry{key: key, value: value})
+}
+
+func (m *LoxMap) String() string {
+	return m.format(map[interface{}]bool{})
+}
+
+func (m *LoxMap) format(seen map[interface{}]bool) string {
+	if seen[m] {
+		return "{...}"
+	}
+	seen[m] = true
+	defer delete(seen, m)
+
+	parts := make([]string, 0, len(m.entries))
+	for _, entry := range m.entries {
+		parts = append(parts, formatElement(entry.key, seen)+": "+formatElement(entry.value, seen))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// Get 查找 map 的方法
+func (m *LoxMap) Get(name *Token) interface{} {
+	if method, ok := mapMethods[name.lexeme]; ok {
+		return &builtinMethod{
+			class:    "map",
+			name:     name.lexeme,
+			minArity: method.arity,
+			maxArity: method.arity,
+			fn: func(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+				return method.fn(m, paren, arguments)
+			},
+		}
+	}
+	panic(NewRuntimeError(name, "Undefined property '"+name.lexeme+"'."))
+}
+
+var mapMethods = map[string]struct {
+	arity int
+	fn    func(m *LoxMap, paren *Token, arguments []interface{}) interface{}
+}{
+	"keys":   {0, (*LoxMap).keys},
+	"values": {0, (*LoxMap).values},
+	"has":    {1, (*LoxMap).has},
+	"remove": {1, (*LoxMap).remove},
+	"len":    {0, (*LoxMap).len},
+}
+
+func (m *LoxMap) keys(paren *Token, arguments []interface{}) interface{} {
+	return NewLoxList(m.Keys())
+}
+
+func (m *LoxMap) values(paren *Token, arguments []interface{}) interface{} {
+	values := make([]interface{}, 0, len(m.entries))
+	for _, entry := range m.entries {
+		values = append(values, entry.value)
+	}
+	return NewLoxList(values)
+}
+
+func (m *LoxMap) has(paren *Token, arguments []interface{}) interface{} {
+	_, ok := m.lookup(paren, arguments[0])
+	return ok
+}
+
+// remove 删除键并返回原来的值，键不存在时返回 nil
+func (m *LoxMap) remove(paren *Token, arguments []interface{}) interface{} {
+	hash := hashKey(paren, arguments[0])
+	position, ok := m.index[hash]
+	if !ok {
+		return nil
+	}
+	value := m.entries[position].value
+
+	delete(m.index, hash)
+	m.entries = append(m.entries[:position], m.entries[position+1:]...)
+	for index := position; index < len(m.entries); index++ {
+		m.index[hashKey(paren, m.entries[index].key)] = index
+	}
+	return value
+}
+
+func (m *LoxMap) len(paren *Token, arguments []interface{}) interface{} {
+	return int64(len(m.entries))
+}
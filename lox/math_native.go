@@ -0,0 +1,190 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// defineMathModule 注册 math 模块，脚本可以直接用 math.sqrt(2)，也可以 import "math" 导入
+func (i *Interpreter) defineMathModule() {
+	_ = i.DefineModule("math", map[string]interface{}{
+		"pi":  math.Pi,
+		"e":   math.E,
+		"inf": math.Inf(1),
+		"nan": math.NaN(),
+
+		"sqrt":  math.Sqrt,
+		"exp":   math.Exp,
+		"log":   math.Log,
+		"log2":  math.Log2,
+		"log10": math.Log10,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+		"atan2": math.Atan2,
+		"isNan": math.IsNaN,
+		"pow":   mathPow,
+		"abs":   mathAbs,
+		"floor": func(x interface{}) (interface{}, error) { return mathRound("floor", x, math.Floor, roundFloor) },
+		"ceil":  func(x interface{}) (interface{}, error) { return mathRound("ceil", x, math.Ceil, roundCeil) },
+		"round": func(x interface{}) (interface{}, error) { return mathRound("round", x, math.Round, roundHalfAway) },
+		"min": func(first interface{}, rest ...interface{}) (interface{}, error) {
+			return mathExtreme("min", -1, first, rest)
+		},
+		"max": func(first interface{}, rest ...interface{}) (interface{}, error) {
+			return mathExtreme("max", 1, first, rest)
+		},
+	})
+}
+
+func numberArgumentError(name string, value interface{}) error {
+	return fmt.Errorf("Argument of '%s' must be a number, got %s.", name, formatElement(value, map[interface{}]bool{}))
+}
+
+// powTooLarge 估计 bits 位的数的 n 次方是否超过 maxNumberBits，绝对值不超过 1 的数乘方后不会变大
+func powTooLarge(bits int, n int64) bool {
+	return bits > 1 && n > 0 && int64(bits) > maxNumberBits/n
+}
+
+// mathPow 计算 base 的 exponent 次方。整数、大整数和小数的非负整数次方是精确的，
+// 整数的结果超出 int64 时提升为大整数，和 * 一样；其余情况按浮点数计算
+func mathPow(base interface{}, exponent interface{}) (interface{}, error) {
+	if !isNumber(base) {
+		return nil, numberArgumentError("pow", base)
+	}
+	if !isNumber(exponent) {
+		return nil, numberArgumentError("pow", exponent)
+	}
+
+	if n, ok := exponent.(int64); ok && n >= 0 {
+		switch b := base.(type) {
+		case int64:
+			if powTooLarge(big.NewInt(b).BitLen(), n) {
+				return nil, fmt.Errorf("Result of pow is too large.")
+			}
+			result := new(big.Int).Exp(big.NewInt(b), big.NewInt(n), nil)
+			if !result.IsInt64() {
+				return result, nil
+			}
+			return result.Int64(), nil
+		case *big.Int:
+			if powTooLarge(b.BitLen(), n) {
+				return nil, fmt.Errorf("Result of pow is too large.")
+			}
+			return new(big.Int).Exp(b, big.NewInt(n), nil), nil
+		case *Decimal:
+			// 小数的位数和小数点后的位数都随指数成倍增长
+			if powTooLarge(b.unscaled.BitLen(), n) || (b.scale > 0 && n > 0 && int64(b.scale) > maxNumberBits/n) {
+				return nil, fmt.Errorf("Result of pow is too large.")
+			}
+			result := decimalFromInt(big.NewInt(1))
+			for square := b; n > 0; n >>= 1 {
+				if n&1 == 1 {
+					result = result.mul(square)
+				}
+				square = square.mul(square)
+			}
+			return result, nil
+		}
+	}
+
+	x, _ := toFloat(base)
+	y, _ := toFloat(exponent)
+	return math.Pow(x, y), nil
+}
+
+func mathAbs(x interface{}) (interface{}, error) {
+	switch v := x.(type) {
+	case int64:
+		if v == math.MinInt64 {
+			return new(big.Int).Neg(big.NewInt(v)), nil
+		}
+		if v < 0 {
+			return -v, nil
+		}
+		return v, nil
+	case *big.Int:
+		return new(big.Int).Abs(v), nil
+	case *Decimal:
+		if v.unscaled.Sign() < 0 {
+			return v.neg(), nil
+		}
+		return v, nil
+	case float64:
+		return math.Abs(v), nil
+	}
+	return nil, numberArgumentError("abs", x)
+}
+
+// 精确数值取整的方式，参数是 num/denom 截断后的商和余数
+type roundMode func(quo *big.Int, rem *big.Int, denom *big.Int) *big.Int
+
+func roundFloor(quo *big.Int, rem *big.Int, denom *big.Int) *big.Int {
+	if rem.Sign() < 0 {
+		return quo.Sub(quo, big.NewInt(1))
+	}
+	return quo
+}
+
+func roundCeil(quo *big.Int, rem *big.Int, denom *big.Int) *big.Int {
+	if rem.Sign() > 0 {
+		return quo.Add(quo, big.NewInt(1))
+	}
+	return quo
+}
+
+// roundHalfAway 和 math.Round 一样，.5 向远离 0 的方向舍入
+func roundHalfAway(quo *big.Int, rem *big.Int, denom *big.Int) *big.Int {
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	if twice.Cmp(denom) >= 0 {
+		return quo.Add(quo, big.NewInt(int64(rem.Sign())))
+	}
+	return quo
+}
+
+// mathRound 实现 floor、ceil 和 round。结果是整数：能放进 int64 时返回整数，
+// 小数取整后超出 int64 时返回大整数，超出 int64 的浮点数、无穷大和 NaN 原样返回浮点数
+func mathRound(name string, x interface{}, floatRound func(float64) float64, mode roundMode) (interface{}, error) {
+	switch v := x.(type) {
+	case int64, *big.Int:
+		return v, nil
+	case float64:
+		f := floatRound(v)
+		if n, ok := floatToInt(f); ok {
+			return n, nil
+		}
+		return f, nil
+	case *Decimal:
+		r := v.Rat()
+		denom := r.Denom()
+		quo, rem := new(big.Int).QuoRem(r.Num(), denom, new(big.Int))
+		result := mode(quo, rem, denom)
+		if result.IsInt64() {
+			return result.Int64(), nil
+		}
+		return result, nil
+	}
+	return nil, numberArgumentError(name, x)
+}
+
+// mathExtreme 实现 min 和 max，sign 为 -1 时取最小值。返回原来的值，不改变数值类型；有 NaN 时结果是 NaN
+func mathExtreme(name string, sign int, first interface{}, rest []interface{}) (interface{}, error) {
+	result := first
+	for _, value := range append([]interface{}{first}, rest...) {
+		if !isNumber(value) {
+			return nil, numberArgumentError(name, value)
+		}
+		if f, ok := value.(float64); ok && math.IsNaN(f) {
+			return f, nil
+		}
+		if compareNumbers(value, result) == sign {
+			result = value
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,248 @@
+package lox
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// LoxModule 是 import 得到的模块对象，通过 m.name 访问模块导出的名字
+type LoxModule struct {
+	name    string
+	path    string
+	env     *Environment
+	exports map[string]bool
+}
+
+func NewLoxModule(name string, path string) *LoxModule {
+	m := &LoxModule{
+		name:    name,
+		path:    path,
+		exports: make(map[string]bool),
+	}
+	return m
+}
+
+func (m *LoxModule) String() string {
+	return "<module " + m.name + ">"
+}
+
+// Path 返回模块文件的绝对路径
+func (m *LoxModule) Path() string {
+	return m.path
+}
+
+// Get 读取模块导出的名字，总是返回模块中的当前值
+func (m *LoxModule) Get(name *Token) interface{} {
+	if !m.exports[name.lexeme] {
+		panic(NewRuntimeError(name, "Module '"+m.name+"' has no export '"+name.lexeme+"'."))
+	}
+	return m.env.values[name.lexeme]
+}
+
+// DefineModule 注册一个原生模块，它同时是名为 name 的内置变量，也可以用 import "name" 导入。
+// members 中的 Go 函数包装成原生函数，其他值按 DefineGlobal 的规则转换
+func (i *Interpreter) DefineModule(name string, members map[string]interface{}) error {
+	module := NewLoxModule(name, "")
+	module.env = NewEnvironment(nil)
+	for member, value := range members {
+		if reflect.ValueOf(value).Kind() == reflect.Func {
+			native, err := NewNativeFunction(member, value)
+			if err != nil {
+				return err
+			}
+			module.env.define(member, native)
+		} else {
+			module.env.define(member, goToLox(value))
+		}
+		module.exports[member] = true
+	}
+
+	if i.nativeModules == nil {
+		i.nativeModules = make(map[string]*LoxModule)
+	}
+	i.nativeModules[name] = module
+	i.builtins.define(name, module)
+	return nil
+}
+
+// moduleLoader 查找、加载并缓存模块，每个 VM 一个
+type moduleLoader struct {
+	vm *VM
+	// 按绝对路径缓存已经加载和正在加载的模块
+	modules map[string]*LoxModule
+	// 正在加载的模块文件，用来发现循环导入
+	loading []string
+	// 查找模块的目录，在导入方所在目录之后、LOX_PATH 之前查找
+	searchPath []string
+}
+
+func newModuleLoader(vm *VM) *moduleLoader {
+	l := &moduleLoader{
+		vm:      vm,
+		modules: make(map[string]*LoxModule),
+	}
+	return l
+}
+
+// WithModulePath 添加查找模块的目录。没有通过 WithSystemAccess 允许访问文件系统时，
+// 脚本只能导入这些目录里面的文件和 DefineModule 注册的模块
+func WithModulePath(dirs ...string) Option {
+	return func(vm *VM) {
+		vm.interpreter.loader.searchPath = append(vm.interpreter.loader.searchPath, dirs...)
+	}
+}
+
+// find 返回模块文件的绝对路径。相对路径依次在导入方所在目录、searchPath 和环境变量 LOX_PATH 中查找，
+// 可以省略 .lox 后缀。不允许访问文件系统时只在 searchPath 中查找，而且不能用绝对路径或 .. 跳出这些目录
+func (l *moduleLoader) find(from *Token, path string) (string, bool) {
+	if filepath.Ext(path) != ".lox" {
+		path += ".lox"
+	}
+
+	var candidates []string
+	if !l.vm.systemAccess {
+		if filepath.IsAbs(path) {
+			return "", false
+		}
+		for _, dir := range l.searchPath {
+			candidate := filepath.Join(dir, path)
+			if rel, err := filepath.Rel(dir, candidate); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				candidates = append(candidates, candidate)
+			}
+		}
+	} else if filepath.IsAbs(path) {
+		candidates = append(candidates, path)
+	} else {
+		base := "."
+		if from.src != nil && from.src.Name != "" {
+			base = filepath.Dir(from.src.Name)
+		}
+		candidates = append(candidates, filepath.Join(base, path))
+		dirs := append(append([]string{}, l.searchPath...), filepath.SplitList(os.Getenv("LOX_PATH"))...)
+		for _, dir := range dirs {
+			if dir != "" {
+				candidates = append(candidates, filepath.Join(dir, path))
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			if absolute, err := filepath.Abs(candidate); err == nil {
+				return absolute, true
+			}
+		}
+	}
+	return "", false
+}
+
+// load 加载 path 指向的模块，同一个文件只执行一次。出错时在 token 处抛出运行时错误，
+// 模块中的静态错误已经通过 VM 报告过了
+func (l *moduleLoader) load(token *Token, path string) *LoxModule {
+	file, ok := l.find(token, path)
+	if !ok {
+		panic(NewRuntimeError(token, "Can't find module '"+path+"'."))
+	}
+
+	if module, ok := l.modules[file]; ok {
+		for index, loading := range l.loading {
+			if loading == file {
+				panic(NewRuntimeError(token, "Import cycle detected: "+cycleDescription(append(l.loading[index:], file))+"."))
+			}
+		}
+		return module
+	}
+
+	text, err := os.ReadFile(file)
+	if err != nil {
+		panic(NewRuntimeError(token, "Can't read module '"+path+"': "+err.Error()))
+	}
+
+	module := NewLoxModule(strings.TrimSuffix(filepath.Base(file), ".lox"), file)
+	l.modules[file] = module
+	l.loading = append(l.loading, file)
+	succeeded := false
+	defer func() {
+		l.loading = l.loading[:len(l.loading)-1]
+		// 加载失败的模块不缓存，下次导入时重新加载
+		if !succeeded {
+			delete(l.modules, file)
+		}
+	}()
+
+	errorCount := len(l.vm.errors)
+	tokens := NewScanner(l.vm, &Source{Name: file, Text: string(text)}).scanTokens()
+	statements := NewParse(l.vm, tokens).parse()
+	if len(l.vm.errors) == errorCount {
+		NewResolver(l.vm).resolveStmt(statements)
+	}
+	if len(l.vm.errors) > errorCount {
+		panic(NewRuntimeError(token, "Module '"+path+"' has errors."))
+	}
+
+	l.vm.interpreter.executeModule(module, statements)
+	succeeded = true
+	return module
+}
+
+func cycleDescription(files []string) string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	return strings.Join(names, " -> ")
+}
+
+// executeModule 在模块自己的全局环境中执行模块，然后检查导出的名字
+func (i *Interpreter) executeModule(module *LoxModule, statements []Stmt) {
+	previousGlobals, previousEnv := i.globals, i.env
+	defer func() {
+		i.globals, i.env = previousGlobals, previousEnv
+	}()
+
+	module.env = NewEnvironment(i.builtins)
+	i.globals, i.env = module.env, module.env
+	for _, statement := range statements {
+		i.execute(statement)
+	}
+
+	for _, statement := range statements {
+		export, ok := statement.(*ExportStmt)
+		if !ok {
+			continue
+		}
+		for _, name := range export.names {
+			if _, ok := module.env.values[name.lexeme]; !ok {
+				panic(NewRuntimeError(name, "Exported name '"+name.lexeme+"' is not defined."))
+			}
+			module.exports[name.lexeme] = true
+		}
+	}
+}
+
+func (i *Interpreter) VisitImportStmt(stmt *ImportStmt) {
+	path := stmt.path.literal.(string)
+	// 原生模块优先于同名的文件
+	module, ok := i.nativeModules[path]
+	if !ok {
+		if i.loader == nil {
+			panic(NewRuntimeError(stmt.keyword, "Modules are not available."))
+		}
+		module = i.loader.load(stmt.keyword, path)
+	}
+
+	if stmt.alias != nil {
+		i.env.define(stmt.alias.lexeme, module)
+	}
+	for index, name := range stmt.names {
+		i.env.define(stmt.aliases[index].lexeme, module.Get(name))
+	}
+}
+
+func (i *Interpreter) VisitExportStmt(stmt *ExportStmt) {
+	if stmt.declaration != nil {
+		i.execute(stmt.declaration)
+	}
+}
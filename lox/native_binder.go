@@ -0,0 +1,137 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// NativeFunction 通过反射把普通的 Go 函数包装成 LoxCallable。
+// 参数支持 float64/整数/string/bool/interface{} 以及可直接赋值的 Go 类型，支持变长参数；
+// 返回值可以是 0 个、1 个，或者 (值, error)、error。
+type NativeFunction struct {
+	name string
+	fn   reflect.Value
+}
+
+func NewNativeFunction(name string, fn interface{}) (*NativeFunction, error) {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return nil, fmt.Errorf("native '%s' must be a function, got %T", name, fn)
+	}
+
+	if err := checkNativeResults(name, value.Type()); err != nil {
+		return nil, err
+	}
+
+	n := &NativeFunction{
+		name: name,
+		fn:   value,
+	}
+	return n, nil
+}
+
+// checkNativeResults 检查函数类型 t 的返回值能否被 NativeFunction 处理
+func checkNativeResults(name string, t reflect.Type) error {
+	switch t.NumOut() {
+	case 0, 1:
+	case 2:
+		if t.Out(1) != errorType {
+			return fmt.Errorf("native '%s': second result must be error, got %s", name, t.Out(1))
+		}
+	default:
+		return fmt.Errorf("native '%s' returns too many results", name)
+	}
+	return nil
+}
+
+// DefineNative 把 Go 函数注册成名为 name 的内置 Lox 函数，主脚本和所有模块都能使用
+func (i *Interpreter) DefineNative(name string, fn interface{}) error {
+	native, err := NewNativeFunction(name, fn)
+	if err != nil {
+		return err
+	}
+	i.builtins.define(name, native)
+	return nil
+}
+
+// DefineGlobal 定义一个全局变量，Go 的数值会转换成 Lox 的 number，Go 函数会包装成原生函数
+func (i *Interpreter) DefineGlobal(name string, value interface{}) {
+	i.globals.define(name, goToLox(value))
+}
+
+// GetGlobal 读取主脚本的全局变量或内置变量，变量不存在时返回 false
+func (i *Interpreter) GetGlobal(name string) (interface{}, bool) {
+	if value, ok := i.globals.values[name]; ok {
+		return value, true
+	}
+	value, ok := i.builtins.values[name]
+	return value, ok
+}
+
+// Arity 返回最少需要的参数个数，变长参数部分不计算在内
+func (n *NativeFunction) Arity() int {
+	t := n.fn.Type()
+	if t.IsVariadic() {
+		return t.NumIn() - 1
+	}
+	return t.NumIn()
+}
+
+func (n *NativeFunction) Call(interpreter *Interpreter, arguments []interface{}) interface{} {
+	return n.callAt(interpreter, NewToken(TokenType_IDENTIFIER, n.name, nil, 0), arguments)
+}
+
+// callAt 在 paren 处检查参数并调用 Go 函数，所有错误都以 RuntimeError 的形式抛出
+func (n *NativeFunction) callAt(interpreter *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	t := n.fn.Type()
+	if t.IsVariadic() {
+		if len(arguments) < n.Arity() {
+			panic(NewRuntimeError(paren, fmt.Sprintf("Expected at least %d arguments but got %d.", n.Arity(), len(arguments))))
+		}
+	} else {
+		checkArity(paren, n.Arity(), len(arguments))
+	}
+
+	in := make([]reflect.Value, len(arguments))
+	for index, argument := range arguments {
+		var paramType reflect.Type
+		if t.IsVariadic() && index >= n.Arity() {
+			paramType = t.In(n.Arity()).Elem()
+		} else {
+			paramType = t.In(index)
+		}
+		value, ok := fromLoxValue(argument, paramType)
+		if !ok {
+			panic(NewRuntimeError(paren, fmt.Sprintf("Argument %d of '%s' must be %s.", index+1, n.name, describeType(paramType))))
+		}
+		in[index] = value
+	}
+
+	out := n.fn.Call(in)
+	if len(out) == 0 {
+		return nil
+	}
+
+	last := out[len(out)-1]
+	if last.Type() == errorType {
+		if !last.IsNil() {
+			// 回调 Lox 时产生的运行时错误原样传出去，保留位置和超出预算、取消等原因
+			err := last.Interface().(error)
+			var runtimeError *RuntimeError
+			if errors.As(err, &runtimeError) {
+				panic(runtimeError)
+			}
+			panic(NewRuntimeError(paren, err.Error()))
+		}
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return toLoxValue(out[0])
+}
+
+func (n *NativeFunction) String() string {
+	return "<native fn " + n.name + ">"
+}
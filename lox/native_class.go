@@ -0,0 +1,172 @@
+package lox
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// NativeClass 把 Go 结构体暴露成 Lox 类。
+// 只有创建时列出的导出字段和方法才能在脚本中访问，Lox 类也可以用 < 继承原生类。
+type NativeClass struct {
+	name        string
+	goType      reflect.Type
+	constructor *NativeFunction
+	fields      map[string]bool
+	methods     map[string]bool
+}
+
+// NewNativeClass 创建原生类，constructor 必须返回结构体指针（可以附带 error），
+// 它的参数就是脚本中调用这个类时传入的参数。
+func NewNativeClass(name string, constructor interface{}, members ...string) (*NativeClass, error) {
+	fn, err := NewNativeFunction(name, constructor)
+	if err != nil {
+		return nil, err
+	}
+	t := fn.fn.Type()
+	if t.NumOut() == 0 || t.Out(0).Kind() != reflect.Ptr || t.Out(0).Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("native class '%s': constructor must return a struct pointer", name)
+	}
+
+	c := &NativeClass{
+		name:        name,
+		goType:      t.Out(0),
+		constructor: fn,
+		fields:      make(map[string]bool),
+		methods:     make(map[string]bool),
+	}
+	for _, member := range members {
+		if field, ok := c.goType.Elem().FieldByName(member); ok && field.PkgPath == "" {
+			c.fields[member] = true
+		} else if method, ok := c.goType.MethodByName(member); ok {
+			// 方法在创建时检查，不要等到脚本调用时才发现不支持
+			if err := checkNativeResults(name+"."+member, method.Type); err != nil {
+				return nil, err
+			}
+			c.methods[member] = true
+		} else {
+			return nil, fmt.Errorf("native class '%s' has no exported member '%s'", name, member)
+		}
+	}
+	return c, nil
+}
+
+// DefineClass 把原生类注册成同名的内置变量，主脚本和所有模块都能使用
+func (i *Interpreter) DefineClass(class *NativeClass) {
+	i.builtins.define(class.name, class)
+}
+
+// Wrap 把已有的 Go 值包装成这个类的实例，value 必须是构造函数返回的那种指针类型
+func (c *NativeClass) Wrap(value interface{}) (*NativeInstance, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || v.Type() != c.goType || v.IsNil() {
+		return nil, fmt.Errorf("native class '%s' can't wrap %T", c.name, value)
+	}
+	return newNativeInstance(c, v), nil
+}
+
+func (c *NativeClass) String() string {
+	return c.name
+}
+
+func (c *NativeClass) Arity() int {
+	return c.constructor.Arity()
+}
+
+func (c *NativeClass) Call(interpreter *Interpreter, arguments []interface{}) interface{} {
+	return c.callAt(interpreter, NewToken(TokenType_IDENTIFIER, c.name, nil, 0), arguments)
+}
+
+func (c *NativeClass) callAt(interpreter *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	return c.construct(interpreter, paren, arguments)
+}
+
+func (c *NativeClass) construct(interpreter *Interpreter, paren *Token, arguments []interface{}) *NativeInstance {
+	value := c.constructor.callAt(interpreter, paren, arguments)
+	if value == nil {
+		panic(NewRuntimeError(paren, "Native constructor '"+c.name+"' returned nil."))
+	}
+	return newNativeInstance(c, reflect.ValueOf(value))
+}
+
+// zero 返回字段全为零值的实例，子类没有调用 super.init 时使用
+func (c *NativeClass) zero() *NativeInstance {
+	return newNativeInstance(c, reflect.New(c.goType.Elem()))
+}
+
+type NativeInstance struct {
+	class *NativeClass
+	value reflect.Value
+}
+
+func newNativeInstance(class *NativeClass, value reflect.Value) *NativeInstance {
+	n := &NativeInstance{
+		class: class,
+		value: value,
+	}
+	return n
+}
+
+// Value 返回背后的 Go 值
+func (n *NativeInstance) Value() interface{} {
+	return n.value.Interface()
+}
+
+func (n *NativeInstance) String() string {
+	return n.class.name + " instance"
+}
+
+func (n *NativeInstance) Get(name *Token) interface{} {
+	if value, ok := n.get(name.lexeme); ok {
+		return value
+	}
+	panic(NewRuntimeError(name, "Undefined property '"+name.lexeme+"'."))
+}
+
+func (n *NativeInstance) get(name string) (interface{}, bool) {
+	if n.class.fields[name] {
+		return toLoxValue(n.value.Elem().FieldByName(name)), true
+	}
+	if n.class.methods[name] {
+		return &NativeFunction{name: name, fn: n.value.MethodByName(name)}, true
+	}
+	return nil, false
+}
+
+func (n *NativeInstance) hasField(name string) bool {
+	return n.class.fields[name]
+}
+
+func (n *NativeInstance) Set(name *Token, value interface{}) {
+	if !n.hasField(name.lexeme) {
+		panic(NewRuntimeError(name, "Undefined field '"+name.lexeme+"'."))
+	}
+	field := n.value.Elem().FieldByName(name.lexeme)
+	v, ok := fromLoxValue(value, field.Type())
+	if !ok {
+		panic(NewRuntimeError(name, fmt.Sprintf("Field '%s' must be %s.", name.lexeme, describeType(field.Type()))))
+	}
+	field.Set(v)
+}
+
+// nativeSuperInit 是子类 init 中的 super.init，调用原生构造函数并替换实例背后的 Go 值
+type nativeSuperInit struct {
+	class    *NativeClass
+	instance *LoxInstance
+}
+
+func (n *nativeSuperInit) Arity() int {
+	return n.class.Arity()
+}
+
+func (n *nativeSuperInit) Call(interpreter *Interpreter, arguments []interface{}) interface{} {
+	return n.callAt(interpreter, NewToken(TokenType_IDENTIFIER, "init", nil, 0), arguments)
+}
+
+func (n *nativeSuperInit) callAt(interpreter *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	n.instance.native = n.class.construct(interpreter, paren, arguments)
+	return nil
+}
+
+func (n *nativeSuperInit) String() string {
+	return "<native fn init>"
+}
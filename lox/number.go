@@ -0,0 +1,349 @@
+package lox
+
+import (
+	"math"
+	"math/big"
+)
+
+// Lox 的数值有四种：整数 int64、大整数 *big.Int、精确小数 *Decimal 和浮点数 float64。
+// 两个整数运算的结果仍是整数（'/' 除外，它总是做浮点除法），溢出时自动提升为大整数；
+// 不同种类混合运算时按 整数 < 大整数 < 小数 的顺序提升，和浮点数混合时提升为浮点数，
+// 但小数不能和浮点数混合运算，避免精确的金额被悄悄变成近似值。'~/' 和 '%' 都向零取整，和 Go 一致。
+
+// 大整数和小数最多的位数（小数点后的位数也不能超过它），乘法和乘方的结果超过时报错，
+// 避免几次平方就占满内存，而步数和时间预算来不及发现
+const maxNumberBits = 1 << 20
+
+const (
+	kindNone = iota
+	kindInt
+	kindBigInt
+	kindDecimal
+	kindFloat
+)
+
+// numberKind 返回数值的种类，不是数值时返回 kindNone
+func numberKind(value interface{}) int {
+	switch value.(type) {
+	case int64:
+		return kindInt
+	case *big.Int:
+		return kindBigInt
+	case *Decimal:
+		return kindDecimal
+	case float64:
+		return kindFloat
+	}
+	return kindNone
+}
+
+// isNumber 判断 value 是不是 Lox 数值
+func isNumber(value interface{}) bool {
+	return numberKind(value) != kindNone
+}
+
+// toFloat 把数值转换成 float64，大整数和小数可能丢失精度，不是数值时返回 false
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, true
+	case *Decimal:
+		f, _ := v.Rat().Float64()
+		return f, true
+	}
+	return 0, false
+}
+
+// toInt 把值为整数、且在 int64 范围内的数值转换成 int64
+func toInt(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case float64:
+		return floatToInt(v)
+	case *big.Int:
+		return v.Int64(), v.IsInt64()
+	}
+	return 0, false
+}
+
+// floatToInt 把值为整数、且在 int64 范围内的浮点数转换成 int64
+func floatToInt(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// toBigInt 把整数或大整数转换成大整数
+func toBigInt(value interface{}) (*big.Int, bool) {
+	switch v := value.(type) {
+	case int64:
+		return big.NewInt(v), true
+	case *big.Int:
+		return v, true
+	}
+	return nil, false
+}
+
+// toDecimal 把整数、大整数或小数转换成小数
+func toDecimal(value interface{}) (*Decimal, bool) {
+	if d, ok := value.(*Decimal); ok {
+		return d, true
+	}
+	if n, ok := toBigInt(value); ok {
+		return decimalFromInt(n), true
+	}
+	return nil, false
+}
+
+// toRat 把数值精确地转换成有理数，NaN 和无穷大返回 nil
+func toRat(value interface{}) *big.Rat {
+	switch v := value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v)
+	case *big.Int:
+		return new(big.Rat).SetInt(v)
+	case *Decimal:
+		return v.Rat()
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return new(big.Rat).SetFloat64(v)
+	}
+	return nil
+}
+
+// numbersEqual 按数学上的值比较两个数值，所以 1 == 1.0 == 1n == 1.00m
+func numbersEqual(a interface{}, b interface{}) bool {
+	switch x := a.(type) {
+	case int64:
+		if y, ok := b.(int64); ok {
+			return x == y
+		}
+	case float64:
+		if y, ok := b.(float64); ok {
+			return x == y
+		}
+	}
+	x, y := toRat(a), toRat(b)
+	return x != nil && y != nil && x.Cmp(y) == 0
+}
+
+// compareNumbers 比较两个数值的大小，返回 -1、0 或 1
+func compareNumbers(a interface{}, b interface{}) int {
+	x, y := toRat(a), toRat(b)
+	if x != nil && y != nil {
+		return x.Cmp(y)
+	}
+	// NaN 和无穷大只能按浮点数比较
+	f1, _ := toFloat(a)
+	f2, _ := toFloat(b)
+	switch {
+	case f1 < f2:
+		return -1
+	case f1 > f2:
+		return 1
+	}
+	return 0
+}
+
+// negate 计算 -value
+func (i *Interpreter) negate(operator *Token, value interface{}) interface{} {
+	switch v := value.(type) {
+	case int64:
+		if v == math.MinInt64 {
+			return new(big.Int).Neg(big.NewInt(v))
+		}
+		return -v
+	case float64:
+		return -v
+	case *big.Int:
+		return new(big.Int).Neg(v)
+	case *Decimal:
+		return v.neg()
+	}
+	panic(NewRuntimeError(operator, "Operand must be a number."))
+}
+
+// arithmetic 计算数值的二元运算，包括比较
+func (i *Interpreter) arithmetic(operator *Token, left interface{}, right interface{}) interface{} {
+	a, ok1 := left.(int64)
+	b, ok2 := right.(int64)
+	if ok1 && ok2 {
+		return i.intArithmetic(operator, a, b)
+	}
+
+	leftKind, rightKind := numberKind(left), numberKind(right)
+	if leftKind == kindNone || rightKind == kindNone {
+		panic(NewRuntimeError(operator, "Operands must be a numbers."))
+	}
+	if (leftKind == kindDecimal && rightKind == kindFloat) || (leftKind == kindFloat && rightKind == kindDecimal) {
+		panic(NewRuntimeError(operator, "Cannot mix decimal and float operands, convert with decimal() first."))
+	}
+
+	switch maxInt(leftKind, rightKind) {
+	case kindBigInt:
+		x, _ := toBigInt(left)
+		y, _ := toBigInt(right)
+		return i.bigIntArithmetic(operator, x, y)
+	case kindDecimal:
+		x, _ := toDecimal(left)
+		y, _ := toDecimal(right)
+		return i.decimalArithmetic(operator, x, y)
+	}
+	x, _ := toFloat(left)
+	y, _ := toFloat(right)
+	return floatArithmetic(operator, x, y)
+}
+
+func (i *Interpreter) intArithmetic(operator *Token, a int64, b int64) interface{} {
+	switch operator.tokenType {
+	case TokenType_PLUS:
+		c := a + b
+		if (c > a) != (b > 0) {
+			return i.bigIntArithmetic(operator, big.NewInt(a), big.NewInt(b))
+		}
+		return c
+	case TokenType_MINUS:
+		c := a - b
+		if (c < a) != (b > 0) {
+			return i.bigIntArithmetic(operator, big.NewInt(a), big.NewInt(b))
+		}
+		return c
+	case TokenType_STAR:
+		if a == 0 || b == 0 {
+			return int64(0)
+		}
+		c := a * b
+		if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+			return i.bigIntArithmetic(operator, big.NewInt(a), big.NewInt(b))
+		}
+		return c
+	case TokenType_SLASH:
+		return float64(a) / float64(b)
+	case TokenType_TILDE_SLASH:
+		if b == 0 {
+			panic(NewRuntimeError(operator, "Division by zero."))
+		}
+		if a == math.MinInt64 && b == -1 {
+			return i.bigIntArithmetic(operator, big.NewInt(a), big.NewInt(b))
+		}
+		return a / b
+	case TokenType_PERCENT:
+		if b == 0 {
+			panic(NewRuntimeError(operator, "Division by zero."))
+		}
+		return a % b
+	case TokenType_GREATER:
+		return a > b
+	case TokenType_GREATER_EQUAL:
+		return a >= b
+	case TokenType_LESS:
+		return a < b
+	case TokenType_LESS_EQUAL:
+		return a <= b
+	}
+	return nil
+}
+
+func (i *Interpreter) bigIntArithmetic(operator *Token, a *big.Int, b *big.Int) interface{} {
+	switch operator.tokenType {
+	case TokenType_PLUS:
+		return new(big.Int).Add(a, b)
+	case TokenType_MINUS:
+		return new(big.Int).Sub(a, b)
+	case TokenType_STAR:
+		if a.BitLen()+b.BitLen() > maxNumberBits {
+			panic(NewRuntimeError(operator, "Result of multiplication is too large."))
+		}
+		return new(big.Int).Mul(a, b)
+	case TokenType_SLASH, TokenType_TILDE_SLASH, TokenType_PERCENT:
+		if b.Sign() == 0 {
+			panic(NewRuntimeError(operator, "Division by zero."))
+		}
+		switch operator.tokenType {
+		case TokenType_SLASH:
+			// 和整数一样，'/' 做浮点除法，溢出提升成大整数之后结果的种类不变
+			f, _ := new(big.Rat).SetFrac(a, b).Float64()
+			return f
+		case TokenType_TILDE_SLASH:
+			return new(big.Int).Quo(a, b)
+		}
+		return new(big.Int).Rem(a, b)
+	}
+	return compare(operator, a.Cmp(b))
+}
+
+func (i *Interpreter) decimalArithmetic(operator *Token, a *Decimal, b *Decimal) interface{} {
+	switch operator.tokenType {
+	case TokenType_PLUS:
+		return a.add(b)
+	case TokenType_MINUS:
+		return a.sub(b)
+	case TokenType_STAR:
+		if a.unscaled.BitLen()+b.unscaled.BitLen() > maxNumberBits || a.scale+b.scale > maxNumberBits {
+			panic(NewRuntimeError(operator, "Result of multiplication is too large."))
+		}
+		return a.mul(b)
+	case TokenType_SLASH, TokenType_TILDE_SLASH, TokenType_PERCENT:
+		if b.unscaled.Sign() == 0 {
+			panic(NewRuntimeError(operator, "Division by zero."))
+		}
+		switch operator.tokenType {
+		case TokenType_SLASH:
+			return a.quo(b)
+		case TokenType_TILDE_SLASH:
+			return a.truncQuo(b)
+		}
+		return a.rem(b)
+	}
+	return compare(operator, a.cmp(b))
+}
+
+// compare 根据比较结果 c（-1、0、1）计算比较运算符的值
+func compare(operator *Token, c int) interface{} {
+	switch operator.tokenType {
+	case TokenType_GREATER:
+		return c > 0
+	case TokenType_GREATER_EQUAL:
+		return c >= 0
+	case TokenType_LESS:
+		return c < 0
+	case TokenType_LESS_EQUAL:
+		return c <= 0
+	}
+	return nil
+}
+
+func floatArithmetic(operator *Token, x float64, y float64) interface{} {
+	switch operator.tokenType {
+	case TokenType_PLUS:
+		return x + y
+	case TokenType_MINUS:
+		return x - y
+	case TokenType_STAR:
+		return x * y
+	case TokenType_SLASH:
+		return x / y
+	case TokenType_TILDE_SLASH:
+		return math.Trunc(x / y)
+	case TokenType_PERCENT:
+		return math.Mod(x, y)
+	case TokenType_GREATER:
+		return x > y
+	case TokenType_GREATER_EQUAL:
+		return x >= y
+	case TokenType_LESS:
+		return x < y
+	case TokenType_LESS_EQUAL:
+		return x <= y
+	}
+	return nil
+}
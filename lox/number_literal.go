@@ -0,0 +1,175 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var radixNames = map[int]string{
+	2:  "binary",
+	8:  "octal",
+	16: "hexadecimal",
+}
+
+// parseNumber 解析数字字面量。后缀 n 表示大整数，例如 123n、0xFFn；后缀 m 表示精确小数，例如 19.99m。
+// 出错时返回错误信息，由扫描器在字面量的位置报告。
+func parseNumber(text string, base int) (interface{}, string) {
+	switch {
+	case strings.HasSuffix(text, "n"):
+		return parseBigInt(text[:len(text)-1], base)
+	case strings.HasSuffix(text, "m") && base == 10:
+		return parseDecimalLiteral(text[:len(text)-1])
+	case base == 10:
+		return parseDecimal(text)
+	}
+	return parseRadix(text, base)
+}
+
+// parseDecimal 解析十进制数字字面量，例如 123、1.5、1e-9、1_000_000。
+// 不带小数点和指数的是整数，超出 int64 范围的整数是大整数，和运算溢出时一样。
+func parseDecimal(text string) (interface{}, string) {
+	if message := checkDecimal(text); message != "" {
+		return 0, message
+	}
+
+	text = strings.ReplaceAll(text, "_", "")
+	if !strings.ContainsAny(text, ".eE") {
+		if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return value, ""
+		}
+		if value, ok := new(big.Int).SetString(text, 10); ok {
+			return value, ""
+		}
+	}
+
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, "Number literal out of range."
+		}
+		return 0, "Invalid number literal."
+	}
+	return value, ""
+}
+
+// parseRadix 解析带 0x、0o、0b 前缀的整数字面量，超出 int64 范围时是大整数
+func parseRadix(text string, base int) (interface{}, string) {
+	if message := checkRadix(text, base); message != "" {
+		return 0, message
+	}
+
+	digits := strings.ReplaceAll(text[2:], "_", "")
+	if value, err := strconv.ParseInt(digits, base, 64); err == nil {
+		return value, ""
+	}
+	value, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return 0, "Invalid number literal."
+	}
+	return value, ""
+}
+
+// parseBigInt 解析去掉后缀 n 的大整数字面量
+func parseBigInt(text string, base int) (interface{}, string) {
+	digits := text
+	if base == 10 {
+		if message := checkDecimal(text); message != "" {
+			return 0, message
+		}
+		if strings.ContainsAny(text, ".eE") {
+			return 0, "Bigint literal must be an integer."
+		}
+	} else {
+		if message := checkRadix(text, base); message != "" {
+			return 0, message
+		}
+		digits = text[2:]
+	}
+
+	value, ok := new(big.Int).SetString(strings.ReplaceAll(digits, "_", ""), base)
+	if !ok {
+		return 0, "Invalid number literal."
+	}
+	return value, ""
+}
+
+// parseDecimalLiteral 解析去掉后缀 m 的小数字面量
+func parseDecimalLiteral(text string) (interface{}, string) {
+	if message := checkDecimal(text); message != "" {
+		return 0, message
+	}
+
+	// 格式已经检查过，解析失败只可能是指数超出范围
+	value, ok := parseDecimalText(strings.ReplaceAll(text, "_", ""))
+	if !ok {
+		return 0, "Number literal out of range."
+	}
+	return value, ""
+}
+
+// checkDecimal 检查十进制字面量的格式，返回错误信息
+func checkDecimal(text string) string {
+	exponent := strings.IndexAny(text, "eE")
+	for i, c := range text {
+		switch {
+		case c >= '0' && c <= '9', c == '.', c == '_':
+		case i == exponent:
+		case exponent >= 0 && i == exponent+1 && (c == '+' || c == '-'):
+		default:
+			return fmt.Sprintf("Invalid character '%c' in number literal.", c)
+		}
+	}
+	if exponent >= 0 && strings.TrimLeft(text[exponent+1:], "+-") == "" {
+		return "Missing digits in exponent."
+	}
+	if !validSeparators(text, 10) {
+		return "Invalid '_' in number literal, it must be between digits."
+	}
+	return ""
+}
+
+// checkRadix 检查带前缀的整数字面量的格式，返回错误信息
+func checkRadix(text string, base int) string {
+	prefix, digits := text[:2], text[2:]
+	if digits == "" {
+		return fmt.Sprintf("Missing digits after '%s'.", prefix)
+	}
+	for _, c := range digits {
+		if c != '_' && digitValue(c) >= base {
+			return fmt.Sprintf("Invalid digit '%c' in %s literal.", c, radixNames[base])
+		}
+	}
+	if !validSeparators(digits, base) {
+		return "Invalid '_' in number literal, it must be between digits."
+	}
+	return ""
+}
+
+// validSeparators 检查数字分隔符 '_' 两边都是数字
+func validSeparators(text string, base int) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(text)-1 || digitValue(rune(text[i-1])) >= base || digitValue(rune(text[i+1])) >= base {
+			return false
+		}
+	}
+	return true
+}
+
+// digitValue 返回数字字符代表的值，不是数字时返回一个足够大的数
+func digitValue(c rune) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return 36
+}
@@ -0,0 +1,68 @@
+package lox
+
+import (
+	"fmt"
+	"lox_go/util"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// defineNumberNatives 注册数值转换相关的内置函数
+func (i *Interpreter) defineNumberNatives() {
+	for name, fn := range map[string]interface{}{
+		"bigint":  nativeBigInt,
+		"decimal": nativeDecimal,
+	} {
+		native, _ := NewNativeFunction(name, fn)
+		i.builtins.define(name, native)
+	}
+}
+
+// nativeBigInt 实现 bigint(value)，把整数、值为整数的浮点数或小数、以及字符串转换成大整数。
+// 字符串可以带 0x、0o、0b 前缀和 '_' 分隔符。
+func nativeBigInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return big.NewInt(v), nil
+	case *big.Int:
+		return v, nil
+	case float64:
+		if !math.IsInf(v, 0) && !math.IsNaN(v) && v == math.Trunc(v) {
+			n, _ := new(big.Float).SetFloat64(v).Int(nil)
+			return n, nil
+		}
+	case *Decimal:
+		if v.isInt() {
+			return v.rescale(0), nil
+		}
+	case string:
+		if n, ok := new(big.Int).SetString(strings.TrimSpace(v), 0); ok {
+			return n, nil
+		}
+		return nil, fmt.Errorf("Invalid bigint '%s'.", v)
+	}
+	return nil, fmt.Errorf("Cannot convert %s to bigint.", util.GetInterfaceToString(value))
+}
+
+// nativeDecimal 实现 decimal(value)，把整数、大整数、浮点数和字符串转换成精确小数。
+// 浮点数按最短的十进制表示转换，所以 decimal(0.1) 就是 0.1。
+func nativeDecimal(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64, *big.Int, *Decimal:
+		d, _ := toDecimal(v)
+		return d, nil
+	case float64:
+		if !math.IsInf(v, 0) && !math.IsNaN(v) {
+			d, _ := parseDecimalText(strconv.FormatFloat(v, 'f', -1, 64))
+			return d, nil
+		}
+	case string:
+		if d, err := NewDecimal(strings.TrimSpace(v)); err == nil {
+			return d, nil
+		}
+		return nil, fmt.Errorf("Invalid decimal '%s'.", v)
+	}
+	return nil, fmt.Errorf("Cannot convert %s to decimal.", util.GetInterfaceToString(value))
+}
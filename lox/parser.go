@@ -1,50 +1,139 @@
 package lox
 
 type Parser struct {
+	vm      *VM
 	tokens  []*Token
 	current int
 }
 
-func NewParse(tokens []*Token) *Parser {
+func NewParse(vm *VM, tokens []*Token) *Parser {
 	p := &Parser{
+		vm:      vm,
 		tokens:  tokens,
 		current: 0,
 	}
 	return p
 }
 
+// parse 解析全部语句。遇到语法错误时跳到下一条语句继续解析，所有错误都会报告给 VM
 func (p *Parser) parse() []Stmt {
-	defer func() {
-		if err := recover(); err != nil {
-			//v, ok := err.(*RuntimeError)
-			//if ok {
-			//	reportRuntimeError(v)
-			//} else {
-			//	panic(err)
-			//}
-		}
-	}()
 	statements := make([]Stmt, 0, 4)
 	for !p.isAtEnd() {
-		statements = append(statements, p.declaration())
+		if stmt := p.declaration(); stmt != nil {
+			statements = append(statements, stmt)
+		}
 	}
 	return statements
 }
 
-func (p *Parser) declaration() Stmt {
+// declaration 解析一条声明，出错时同步到下一条语句的开头并返回 nil
+func (p *Parser) declaration() (stmt Stmt) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*ParseError); !ok {
+				panic(r)
+			}
+			p.synchronize()
+			stmt = nil
+		}
+	}()
+
 	if p.match(TokenType_CLASS) {
 		return p.classDeclaration()
 	}
-	if p.match(TokenType_FUN) {
+	// fun 后面不是函数名的是函数表达式，按表达式语句处理
+	if p.check(TokenType_FUN) && p.checkNext(TokenType_IDENTIFIER) {
+		p.advance()
 		return p.function("function")
 	}
 	if p.match(TokenType_VAR) {
 		return p.varDeclaration()
 	}
+	if p.match(TokenType_IMPORT) {
+		return p.importDeclaration()
+	}
+	if p.match(TokenType_EXPORT) {
+		return p.exportDeclaration()
+	}
 
 	return p.statement()
 }
 
+// importDeclaration 解析 import "path" as m; 和 import { a, b as c } from "path";
+// as 和 from 不是关键字，只在这里有特殊含义
+func (p *Parser) importDeclaration() Stmt {
+	keyword := p.previous()
+	var alias *Token = nil
+	var names []*Token = nil
+	var aliases []*Token = nil
+
+	if p.match(TokenType_LEFT_BRACE) {
+		for {
+			name := p.consume(TokenType_IDENTIFIER, "Expect imported name.")
+			names = append(names, name)
+			if p.matchWord("as") {
+				name = p.consume(TokenType_IDENTIFIER, "Expect name after 'as'.")
+			}
+			aliases = append(aliases, name)
+			if !p.match(TokenType_COMMA) {
+				break
+			}
+		}
+		p.consume(TokenType_RIGHT_BRACE, "Expect '}' after imported names.")
+		if !p.matchWord("from") {
+			panic(p.error(p.peek(), "Expect 'from' after imported names."))
+		}
+	}
+
+	path := p.consume(TokenType_STRING, "Expect module path.")
+	if names == nil && p.matchWord("as") {
+		alias = p.consume(TokenType_IDENTIFIER, "Expect module name after 'as'.")
+	}
+	p.consume(TokenType_SEMICOLON, "Expect ';' after import.")
+	return NewImportStmt(keyword, path, alias, names, aliases)
+}
+
+// exportDeclaration 解析 export 后面跟的声明，或者 export { a, b }; 形式的导出列表
+func (p *Parser) exportDeclaration() Stmt {
+	keyword := p.previous()
+
+	if p.match(TokenType_LEFT_BRACE) {
+		var names []*Token = nil
+		for {
+			names = append(names, p.consume(TokenType_IDENTIFIER, "Expect exported name."))
+			if !p.match(TokenType_COMMA) {
+				break
+			}
+		}
+		p.consume(TokenType_RIGHT_BRACE, "Expect '}' after exported names.")
+		p.consume(TokenType_SEMICOLON, "Expect ';' after export list.")
+		return NewExportStmt(keyword, nil, names)
+	}
+
+	if p.match(TokenType_FUN) {
+		function := p.function("function")
+		return NewExportStmt(keyword, function, []*Token{function.name})
+	}
+	if p.match(TokenType_VAR) {
+		variable := p.varDeclaration().(*VarStmt)
+		return NewExportStmt(keyword, variable, []*Token{variable.name})
+	}
+	if p.match(TokenType_CLASS) {
+		class := p.classDeclaration().(*ClassStmt)
+		return NewExportStmt(keyword, class, []*Token{class.name})
+	}
+	panic(p.error(p.peek(), "Expect declaration or '{' after 'export'."))
+}
+
+// matchWord 匹配词法上是标识符、但在当前位置有特殊含义的单词
+func (p *Parser) matchWord(word string) bool {
+	if p.check(TokenType_IDENTIFIER) && p.peek().lexeme == word {
+		p.advance()
+		return true
+	}
+	return false
+}
+
 func (p *Parser) classDeclaration() Stmt {
 	name := p.consume(TokenType_IDENTIFIER, "Expect class name.")
 
@@ -67,8 +156,25 @@ func (p *Parser) classDeclaration() Stmt {
 
 func (p *Parser) statement() Stmt {
 
+	// label: while (...) 或 label: for (...)
+	if p.check(TokenType_IDENTIFIER) && p.checkNext(TokenType_COLON) {
+		label := p.advance()
+		p.advance()
+		if p.match(TokenType_FOR) {
+			return p.forStatement(label)
+		}
+		if p.match(TokenType_WHILE) {
+			return p.whileStatement(label)
+		}
+		panic(p.error(p.peek(), "Expect loop after label."))
+	}
+
+	if p.match(TokenType_BREAK, TokenType_CONTINUE) {
+		return p.loopControlStatement()
+	}
+
 	if p.match(TokenType_FOR) {
-		return p.forStatement()
+		return p.forStatement(nil)
 	}
 
 	if p.match(TokenType_IF) {
@@ -83,8 +189,16 @@ func (p *Parser) statement() Stmt {
 		return p.returnStatement()
 	}
 
+	if p.match(TokenType_THROW) {
+		return p.throwStatement()
+	}
+
+	if p.match(TokenType_TRY) {
+		return p.tryStatement()
+	}
+
 	if p.match(TokenType_WHILE) {
-		return p.whileStatement()
+		return p.whileStatement(nil)
 	}
 
 	if p.match(TokenType_LEFT_BRACE) {
@@ -93,7 +207,10 @@ func (p *Parser) statement() Stmt {
 	return p.expressionStatement()
 }
 
-func (p *Parser) forStatement() Stmt {
+// forStatement 把 for 循环脱糖成 while 循环，increment 单独保存在 WhileStmt 中，
+// 这样 continue 之后仍然会执行它
+func (p *Parser) forStatement(label *Token) Stmt {
+	keyword := p.previous()
 	p.consume(TokenType_LEFT_PAREN, "Expect '(' after 'for'.")
 	var initializer Stmt
 	if p.match(TokenType_SEMICOLON) {
@@ -119,15 +236,11 @@ func (p *Parser) forStatement() Stmt {
 
 	body := p.statement()
 
-	if increment != nil {
-		body = NewBlockStmt([]Stmt{body, NewExpressionStmt(increment)})
-	}
-
 	if condition == nil {
 		condition = NewLiteralExpr(true)
 	}
 
-	body = NewWhileStmt(condition, body)
+	body = NewWhileStmt(keyword, condition, body, increment, label)
 
 	if initializer != nil {
 		body = NewBlockStmt([]Stmt{initializer, body})
@@ -179,12 +292,62 @@ func (p *Parser) varDeclaration() Stmt {
 	return NewVarStmt(name, initializer)
 }
 
-func (p *Parser) whileStatement() Stmt {
+func (p *Parser) whileStatement(label *Token) Stmt {
+	keyword := p.previous()
 	p.consume(TokenType_LEFT_PAREN, "Expect '(' after 'while'.")
 	condition := p.expression()
 	p.consume(TokenType_RIGHT_PAREN, "Expect ')' after condition.")
 	body := p.statement()
-	return NewWhileStmt(condition, body)
+	return NewWhileStmt(keyword, condition, body, nil, label)
+}
+
+func (p *Parser) throwStatement() Stmt {
+	keyword := p.previous()
+	value := p.expression()
+	p.consume(TokenType_SEMICOLON, "Expect ';' after thrown value.")
+	return NewThrowStmt(keyword, value)
+}
+
+// tryStatement 解析 try { } catch (e) { } finally { }，catch 和 finally 至少要有一个
+func (p *Parser) tryStatement() Stmt {
+	keyword := p.previous()
+	p.consume(TokenType_LEFT_BRACE, "Expect '{' after 'try'.")
+	body := p.block()
+
+	var catchName *Token = nil
+	var catchBody []Stmt = nil
+	if p.match(TokenType_CATCH) {
+		p.consume(TokenType_LEFT_PAREN, "Expect '(' after 'catch'.")
+		catchName = p.consume(TokenType_IDENTIFIER, "Expect error variable name.")
+		p.consume(TokenType_RIGHT_PAREN, "Expect ')' after error variable.")
+		p.consume(TokenType_LEFT_BRACE, "Expect '{' before catch body.")
+		catchBody = p.block()
+	}
+
+	var finallyBody []Stmt = nil
+	if p.match(TokenType_FINALLY) {
+		p.consume(TokenType_LEFT_BRACE, "Expect '{' after 'finally'.")
+		finallyBody = p.block()
+	}
+
+	if catchName == nil && finallyBody == nil {
+		panic(p.error(p.peek(), "Expect 'catch' or 'finally' after try block."))
+	}
+	return NewTryStmt(keyword, body, catchName, catchBody, finallyBody)
+}
+
+// loopControlStatement 解析 break 和 continue，后面可以跟循环的标签
+func (p *Parser) loopControlStatement() Stmt {
+	keyword := p.previous()
+	var label *Token = nil
+	if p.match(TokenType_IDENTIFIER) {
+		label = p.previous()
+	}
+	p.consume(TokenType_SEMICOLON, "Expect ';' after '"+keyword.lexeme+"'.")
+	if keyword.tokenType == TokenType_BREAK {
+		return NewBreakStmt(keyword, label)
+	}
+	return NewContinueStmt(keyword, label)
 }
 
 func (p *Parser) expressionStatement() Stmt {
@@ -196,11 +359,65 @@ func (p *Parser) expressionStatement() Stmt {
 func (p *Parser) function(kind string) *FunctionStmt {
 	name := p.consume(TokenType_IDENTIFIER, "Expect "+kind+" name.")
 	p.consume(TokenType_LEFT_PAREN, "Expect '(' after "+kind+" name.")
+	parameters := p.parameters()
+	p.consume(TokenType_LEFT_BRACE, "Expect '{' before "+kind+" body.")
+	body := p.block()
+	return NewFunctionStmt(name, parameters, body)
+}
+
+// functionExpression 解析匿名函数 fun (a, b) { ... }，fun 已经被消费
+func (p *Parser) functionExpression() Expr {
+	keyword := p.previous()
+	p.consume(TokenType_LEFT_PAREN, "Expect '(' after 'fun'.")
+	parameters := p.parameters()
+	p.consume(TokenType_LEFT_BRACE, "Expect '{' before function body.")
+	body := p.block()
+	return NewFunctionExpr(keyword, NewFunctionStmt(nil, parameters, body))
+}
+
+// isArrowFunction 向前看判断当前的 ( 是不是箭头函数的参数列表，例如 (a, b) =>
+func (p *Parser) isArrowFunction() bool {
+	index := p.current + 1
+	if p.tokens[index].tokenType != TokenType_RIGHT_PAREN {
+		for {
+			if p.tokens[index].tokenType != TokenType_IDENTIFIER {
+				return false
+			}
+			index++
+			if p.tokens[index].tokenType != TokenType_COMMA {
+				break
+			}
+			index++
+		}
+		if p.tokens[index].tokenType != TokenType_RIGHT_PAREN {
+			return false
+		}
+	}
+	return p.tokens[index+1].tokenType == TokenType_ARROW
+}
+
+// arrowFunction 解析箭头函数 (a, b) => a + b，函数体也可以是代码块
+func (p *Parser) arrowFunction() Expr {
+	p.consume(TokenType_LEFT_PAREN, "Expect '(' before parameters.")
+	parameters := p.parameters()
+	arrow := p.consume(TokenType_ARROW, "Expect '=>' after parameters.")
+
+	var body []Stmt
+	if p.match(TokenType_LEFT_BRACE) {
+		body = p.block()
+	} else {
+		body = []Stmt{NewReturnStmt(arrow, p.expression())}
+	}
+	return NewFunctionExpr(arrow, NewFunctionStmt(nil, parameters, body))
+}
+
+// parameters 解析形参列表直到 )，左括号已经被消费
+func (p *Parser) parameters() []*Token {
 	var parameters []*Token = nil
 	if !p.check(TokenType_RIGHT_PAREN) {
 		for true {
 			if len(parameters) >= 255 {
-				reportErrorToken(p.peek(), "Can't have more than 255 parameters.")
+				p.error(p.peek(), "Can't have more than 255 parameters.")
 			}
 			parameters = append(parameters, p.consume(TokenType_IDENTIFIER, "Expect parameter name."))
 			if !p.match(TokenType_COMMA) {
@@ -209,15 +426,15 @@ func (p *Parser) function(kind string) *FunctionStmt {
 		}
 	}
 	p.consume(TokenType_RIGHT_PAREN, "Expect ')' after parameters.")
-	p.consume(TokenType_LEFT_BRACE, "Expect '{' before "+kind+" body.")
-	body := p.block()
-	return NewFunctionStmt(name, parameters, body)
+	return parameters
 }
 
 func (p *Parser) block() []Stmt {
 	statements := make([]Stmt, 0, 4)
 	for !p.check(TokenType_RIGHT_BRACE) && !p.isAtEnd() {
-		statements = append(statements, p.declaration())
+		if stmt := p.declaration(); stmt != nil {
+			statements = append(statements, stmt)
+		}
 	}
 
 	p.consume(TokenType_RIGHT_BRACE, "Expect '}' after block.")
@@ -237,9 +454,11 @@ func (p *Parser) assignment() Expr {
 			return NewAssignExpr(name, value)
 		case *GetExpr:
 			return NewSetExpr(v.object, v.name, value)
+		case *IndexExpr:
+			return NewIndexSetExpr(v.object, v.bracket, v.index, value)
 		}
 
-		reportErrorToken(equals, "Invalid assignment target.")
+		p.error(equals, "Invalid assignment target.")
 	}
 
 	return expr
@@ -311,6 +530,14 @@ func (p *Parser) check(tokenType TokenType) bool {
 	return p.peek().tokenType == tokenType
 }
 
+// checkNext 判断下一个 token 之后的那个 token 的类型
+func (p *Parser) checkNext(tokenType TokenType) bool {
+	if p.isAtEnd() || p.tokens[p.current+1].tokenType == TokenType_EOF {
+		return false
+	}
+	return p.tokens[p.current+1].tokenType == tokenType
+}
+
 func (p *Parser) advance() *Token {
 	if !p.isAtEnd() {
 		p.current++
@@ -343,7 +570,7 @@ func (p *Parser) term() Expr {
 func (p *Parser) factor() Expr {
 	expr := p.unary()
 
-	for p.match(TokenType_SLASH, TokenType_STAR) {
+	for p.match(TokenType_SLASH, TokenType_STAR, TokenType_TILDE_SLASH, TokenType_PERCENT) {
 		operator := p.previous()
 		right := p.unary()
 		expr = NewBinaryExpr(expr, operator, right)
@@ -365,7 +592,7 @@ func (p *Parser) finishCall(callee Expr) Expr {
 	if !p.check(TokenType_RIGHT_PAREN) {
 		for true {
 			if len(arguments) >= 255 {
-				reportErrorToken(p.peek(), "Can't have more than 255 arguments.")
+				p.error(p.peek(), "Can't have more than 255 arguments.")
 			}
 			arguments = append(arguments, p.expression())
 			if !p.match(TokenType_COMMA) {
@@ -388,6 +615,10 @@ func (p *Parser) call() Expr {
 		} else if p.match(TokenType_DOT) {
 			name := p.consume(TokenType_IDENTIFIER, "Expect property name after '.'.")
 			expr = NewGetExpr(expr, name)
+		} else if p.match(TokenType_LEFT_BRACKET) {
+			index := p.expression()
+			bracket := p.consume(TokenType_RIGHT_BRACKET, "Expect ']' after index.")
+			expr = NewIndexExpr(expr, bracket, index)
 		} else {
 			break
 		}
@@ -426,15 +657,61 @@ func (p *Parser) primary() Expr {
 		return NewVariableExpr(p.previous())
 	}
 
+	if p.match(TokenType_FUN) {
+		return p.functionExpression()
+	}
+
+	if p.check(TokenType_LEFT_PAREN) && p.isArrowFunction() {
+		return p.arrowFunction()
+	}
+
 	if p.match(TokenType_LEFT_PAREN) {
 		expr := p.expression()
 		p.consume(TokenType_RIGHT_PAREN, "Expect ')' after expression.")
 		return NewGroupingExpr(expr)
 	}
 
-	message := "Expect expression."
-	reportErrorToken(p.peek(), message)
-	panic(message)
+	if p.match(TokenType_LEFT_BRACKET) {
+		return p.list()
+	}
+
+	// 语句开头的 { 是代码块，在 block() 中处理；能走到表达式这里的 { 只能是 map 字面量
+	if p.match(TokenType_LEFT_BRACE) {
+		return p.mapLiteral()
+	}
+
+	panic(p.error(p.peek(), "Expect expression."))
+}
+
+// list 解析列表字面量 [a, b, c]，允许最后一个元素后面跟逗号
+func (p *Parser) list() Expr {
+	bracket := p.previous()
+	elements := make([]Expr, 0)
+	for !p.check(TokenType_RIGHT_BRACKET) {
+		elements = append(elements, p.expression())
+		if !p.match(TokenType_COMMA) {
+			break
+		}
+	}
+	p.consume(TokenType_RIGHT_BRACKET, "Expect ']' after list elements.")
+	return NewListExpr(bracket, elements)
+}
+
+// mapLiteral 解析 map 字面量 {key: value, ...}，允许最后一个键值对后面跟逗号
+func (p *Parser) mapLiteral() Expr {
+	brace := p.previous()
+	keys := make([]Expr, 0)
+	values := make([]Expr, 0)
+	for !p.check(TokenType_RIGHT_BRACE) {
+		keys = append(keys, p.expression())
+		p.consume(TokenType_COLON, "Expect ':' after map key.")
+		values = append(values, p.expression())
+		if !p.match(TokenType_COMMA) {
+			break
+		}
+	}
+	p.consume(TokenType_RIGHT_BRACE, "Expect '}' after map entries.")
+	return NewMapExpr(brace, keys, values)
 }
 
 func (p *Parser) consume(tokenType TokenType, message string) *Token {
@@ -442,8 +719,12 @@ func (p *Parser) consume(tokenType TokenType, message string) *Token {
 		return p.advance()
 	}
 
-	reportErrorToken(p.peek(), message)
-	panic(message)
+	panic(p.error(p.peek(), message))
+}
+
+// error 报告语法错误并返回它，调用方通过 panic 它回到 declaration 进行同步
+func (p *Parser) error(token *Token, message string) *ParseError {
+	return p.vm.parseError(token, message)
 }
 
 func (p *Parser) synchronize() {
@@ -455,7 +736,8 @@ func (p *Parser) synchronize() {
 		}
 
 		switch p.peek().tokenType {
-		case TokenType_CLASS, TokenType_FUN, TokenType_VAR, TokenType_FOR, TokenType_IF, TokenType_WHILE, TokenType_PRINT, TokenType_RETURN:
+		case TokenType_CLASS, TokenType_FUN, TokenType_VAR, TokenType_FOR, TokenType_IF, TokenType_WHILE, TokenType_PRINT, TokenType_RETURN,
+			TokenType_BREAK, TokenType_CONTINUE, TokenType_THROW, TokenType_TRY, TokenType_IMPORT, TokenType_EXPORT:
 			return
 		}
 
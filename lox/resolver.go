@@ -22,15 +22,20 @@ const (
 )
 
 type Resolver struct {
+	vm              *VM
 	interpreter     *Interpreter
 	scopes          *stack.Stack[map[string]bool]
 	currentFunction FunctionType
 	currentClass    ClassType
+
+	// 当前所在的循环的标签，由外到内，没有标签的循环为空串
+	loops []string
 }
 
-func NewResolver(interpreter *Interpreter) *Resolver {
+func NewResolver(vm *VM) *Resolver {
 	r := &Resolver{
-		interpreter:     interpreter,
+		vm:              vm,
+		interpreter:     vm.interpreter,
 		scopes:          stack.New[map[string]bool](),
 		currentFunction: FunctionType_None,
 		currentClass:    ClassType_None,
@@ -67,7 +72,7 @@ func (r *Resolver) declare(name *Token) {
 
 	scope := r.scopes.Peek()
 	if _, ok := scope[name.lexeme]; ok {
-		reportErrorToken(name, "Already a variable with this name in this scope.")
+		r.vm.resolveError(name, "Already a variable with this name in this scope.")
 	}
 	scope[name.lexeme] = false
 }
@@ -93,7 +98,7 @@ func (r *Resolver) VisitClassStmt(stmt *ClassStmt) {
 	if stmt.superclass != nil {
 		r.currentClass = ClassType_Subclass
 		if stmt.name.lexeme == stmt.superclass.name.lexeme {
-			reportErrorToken(stmt.superclass.name, "A class can't inherit from itself.")
+			r.vm.resolveError(stmt.superclass.name, "A class can't inherit from itself.")
 		}
 		r.resolveExpr(stmt.superclass)
 	}
@@ -129,7 +134,7 @@ func (r *Resolver) VisitVariableExpr(variableexpr *VariableExpr) {
 	if r.scopes.Size() > 0 {
 		scope := r.scopes.Peek()
 		if value, ok := scope[variableexpr.name.lexeme]; value == false && ok {
-			reportErrorToken(variableexpr.name, "Can't read local variable in its own initializer.")
+			r.vm.resolveError(variableexpr.name, "Can't read local variable in its own initializer.")
 		}
 	}
 	r.resolveLocal(variableexpr, variableexpr.name)
@@ -159,7 +164,10 @@ func (r *Resolver) VisitFunctionStmt(functionstmt *FunctionStmt) {
 
 func (r *Resolver) resolveFunction(functionstmt *FunctionStmt, functionType FunctionType) {
 	enclosingFunction := r.currentFunction
+	enclosingLoops := r.loops
 	r.currentFunction = functionType
+	// break 和 continue 不能跳出函数
+	r.loops = nil
 	r.beginScope()
 	for _, param := range functionstmt.params {
 		r.declare(param)
@@ -168,6 +176,7 @@ func (r *Resolver) resolveFunction(functionstmt *FunctionStmt, functionType Func
 	r.resolveStmt(functionstmt.body)
 	r.endScope()
 	r.currentFunction = enclosingFunction
+	r.loops = enclosingLoops
 }
 
 func (r *Resolver) VisitExpressionStmt(expressionstmt *ExpressionStmt) {
@@ -188,19 +197,100 @@ func (r *Resolver) VisitPrintStmt(printstmt *PrintStmt) {
 
 func (r *Resolver) VisitReturnStmt(returnstmt *ReturnStmt) {
 	if r.currentFunction == FunctionType_None {
-		reportErrorToken(returnstmt.keyword, "Can't return from top-level code.")
+		r.vm.resolveError(returnstmt.keyword, "Can't return from top-level code.")
 	}
 	if returnstmt.value != nil {
 		if r.currentFunction == FunctionType_Initializer {
-			reportErrorToken(returnstmt.keyword, "Can't return a value from an initializer.")
+			r.vm.resolveError(returnstmt.keyword, "Can't return a value from an initializer.")
 		}
 		r.resolveExpr(returnstmt.value)
 	}
 }
 
 func (r *Resolver) VisitWhileStmt(whilestmt *WhileStmt) {
+	label := labelName(whilestmt.label)
+	if label != "" && r.hasLoop(label) {
+		r.vm.resolveError(whilestmt.label, "Label '"+label+"' is already used by an enclosing loop.")
+	}
+
 	r.resolveExpr(whilestmt.condition)
+	r.loops = append(r.loops, label)
 	r.resolveStmtOne(whilestmt.body)
+	r.loops = r.loops[:len(r.loops)-1]
+	if whilestmt.increment != nil {
+		r.resolveExpr(whilestmt.increment)
+	}
+}
+
+func (r *Resolver) VisitImportStmt(importstmt *ImportStmt) {
+	if importstmt.alias != nil {
+		r.declare(importstmt.alias)
+		r.define(importstmt.alias)
+	}
+	for _, alias := range importstmt.aliases {
+		r.declare(alias)
+		r.define(alias)
+	}
+}
+
+func (r *Resolver) VisitExportStmt(exportstmt *ExportStmt) {
+	if r.scopes.Size() > 0 || r.currentFunction != FunctionType_None {
+		r.vm.resolveError(exportstmt.keyword, "Can only export from the top level.")
+	}
+	if exportstmt.declaration != nil {
+		r.resolveStmtOne(exportstmt.declaration)
+	}
+}
+
+func (r *Resolver) VisitThrowStmt(throwstmt *ThrowStmt) {
+	r.resolveExpr(throwstmt.value)
+}
+
+func (r *Resolver) VisitTryStmt(trystmt *TryStmt) {
+	r.beginScope()
+	r.resolveStmt(trystmt.body)
+	r.endScope()
+
+	if trystmt.catchName != nil {
+		r.beginScope()
+		r.declare(trystmt.catchName)
+		r.define(trystmt.catchName)
+		r.resolveStmt(trystmt.catchBody)
+		r.endScope()
+	}
+
+	if trystmt.finallyBody != nil {
+		r.beginScope()
+		r.resolveStmt(trystmt.finallyBody)
+		r.endScope()
+	}
+}
+
+func (r *Resolver) VisitBreakStmt(breakstmt *BreakStmt) {
+	r.resolveLoopControl(breakstmt.keyword, breakstmt.label)
+}
+
+func (r *Resolver) VisitContinueStmt(continuestmt *ContinueStmt) {
+	r.resolveLoopControl(continuestmt.keyword, continuestmt.label)
+}
+
+func (r *Resolver) resolveLoopControl(keyword *Token, label *Token) {
+	if len(r.loops) == 0 {
+		r.vm.resolveError(keyword, "Can't use '"+keyword.lexeme+"' outside of a loop.")
+		return
+	}
+	if label != nil && !r.hasLoop(label.lexeme) {
+		r.vm.resolveError(label, "Undefined loop label '"+label.lexeme+"'.")
+	}
+}
+
+func (r *Resolver) hasLoop(label string) bool {
+	for _, loop := range r.loops {
+		if loop == label {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *Resolver) VisitBinaryExpr(binaryexpr *BinaryExpr) {
@@ -214,6 +304,10 @@ func (r *Resolver) VisitCallExpr(callexpr *CallExpr) {
 		r.resolveExpr(argument)
 	}
 }
+func (r *Resolver) VisitFunctionExpr(functionexpr *FunctionExpr) {
+	r.resolveFunction(functionexpr.declaration, FunctionType_Function)
+}
+
 func (r *Resolver) VisitGetExpr(getexpr *GetExpr) {
 	r.resolveExpr(getexpr.object)
 }
@@ -222,6 +316,30 @@ func (r *Resolver) VisitGroupingExpr(groupingexpr *GroupingExpr) {
 	r.resolveExpr(groupingexpr.expression)
 }
 
+func (r *Resolver) VisitIndexExpr(indexexpr *IndexExpr) {
+	r.resolveExpr(indexexpr.object)
+	r.resolveExpr(indexexpr.index)
+}
+
+func (r *Resolver) VisitIndexSetExpr(indexsetexpr *IndexSetExpr) {
+	r.resolveExpr(indexsetexpr.value)
+	r.resolveExpr(indexsetexpr.object)
+	r.resolveExpr(indexsetexpr.index)
+}
+
+func (r *Resolver) VisitListExpr(listexpr *ListExpr) {
+	for _, element := range listexpr.elements {
+		r.resolveExpr(element)
+	}
+}
+
+func (r *Resolver) VisitMapExpr(mapexpr *MapExpr) {
+	for index := range mapexpr.keys {
+		r.resolveExpr(mapexpr.keys[index])
+		r.resolveExpr(mapexpr.values[index])
+	}
+}
+
 func (r *Resolver) VisitLiteralExpr(literalexpr *LiteralExpr) {
 
 }
@@ -238,16 +356,16 @@ func (r *Resolver) VisitSetExpr(setexpr *SetExpr) {
 
 func (r *Resolver) VisitSuperExpr(superexpr *SuperExpr) {
 	if r.currentClass == ClassType_None {
-		reportErrorToken(superexpr.keyword, "Can't use 'super' outside of a class.")
+		r.vm.resolveError(superexpr.keyword, "Can't use 'super' outside of a class.")
 	} else if r.currentClass != ClassType_Subclass {
-		reportErrorToken(superexpr.keyword, "Can't use 'super' in a class with no superclass.")
+		r.vm.resolveError(superexpr.keyword, "Can't use 'super' in a class with no superclass.")
 	}
 	r.resolveLocal(superexpr, superexpr.keyword)
 }
 
 func (r *Resolver) VisitThisExpr(thisexpr *ThisExpr) {
 	if r.currentClass == ClassType_None {
-		reportErrorToken(thisexpr.keyword, "Can't use 'this' outside of a class.")
+		r.vm.resolveError(thisexpr.keyword, "Can't use 'this' outside of a class.")
 	}
 	r.resolveLocal(thisexpr, thisexpr.keyword)
 }
@@ -5,6 +5,14 @@ import "fmt"
 type RuntimeError struct {
 	Token   *Token
 	Message string
+	// Err 是导致错误的底层原因，例如 ErrBudgetExceeded 或 context.Canceled，可以用 errors.Is 判断
+	Err error
+	// Trace 是出错时的调用栈，从最内层开始
+	Trace []StackFrame
+	// Value 是 throw 语句抛出的 Lox 值，解释器内部产生的错误为 nil
+	Value interface{}
+	// thrown 表示错误来自 throw 语句，用来区分 throw nil
+	thrown bool
 }
 
 func NewRuntimeError(token *Token, message string) *RuntimeError {
@@ -18,3 +26,18 @@ func NewRuntimeError(token *Token, message string) *RuntimeError {
 func (e *RuntimeError) Error() string {
 	return fmt.Sprintf("[line %d]%s", e.Token.line, e.Message)
 }
+
+// Snippet 渲染出错的源码行，并在出错的 token 下面画出 ^ 标记
+func (e *RuntimeError) Snippet() string {
+	return renderSnippet(e.Token.src, e.Token.line, e.Token.column, e.Token.offset, e.Token.length)
+}
+
+func (e *RuntimeError) Unwrap() error {
+	return e.Err
+}
+
+// catchable 判断错误能否被 try/catch 捕获。超出执行预算和被取消的错误带有 Err，
+// 它们必须一直传到宿主，脚本不能把它们吞掉
+func (e *RuntimeError) catchable() bool {
+	return e.Err == nil
+}
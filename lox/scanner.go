@@ -1,39 +1,59 @@
 package lox
 
 import (
-	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 var keywords = map[string]TokenType{
-	"and":    TokenType_AND,
-	"class":  TokenType_CLASS,
-	"else":   TokenType_ELSE,
-	"false":  TokenType_FALSE,
-	"for":    TokenType_FOR,
-	"fun":    TokenType_FUN,
-	"if":     TokenType_IF,
-	"nil":    TokenType_NIL,
-	"or":     TokenType_OR,
-	"print":  TokenType_PRINT,
-	"return": TokenType_RETURN,
-	"super":  TokenType_SUPER,
-	"this":   TokenType_THIS,
-	"true":   TokenType_TRUE,
-	"var":    TokenType_VAR,
-	"while":  TokenType_WHILE,
+	"and":      TokenType_AND,
+	"break":    TokenType_BREAK,
+	"catch":    TokenType_CATCH,
+	"class":    TokenType_CLASS,
+	"continue": TokenType_CONTINUE,
+	"else":     TokenType_ELSE,
+	"export":   TokenType_EXPORT,
+	"false":    TokenType_FALSE,
+	"finally":  TokenType_FINALLY,
+	"for":      TokenType_FOR,
+	"fun":      TokenType_FUN,
+	"if":       TokenType_IF,
+	"import":   TokenType_IMPORT,
+	"nil":      TokenType_NIL,
+	"or":       TokenType_OR,
+	"print":    TokenType_PRINT,
+	"return":   TokenType_RETURN,
+	"super":    TokenType_SUPER,
+	"this":     TokenType_THIS,
+	"throw":    TokenType_THROW,
+	"true":     TokenType_TRUE,
+	"try":      TokenType_TRY,
+	"var":      TokenType_VAR,
+	"while":    TokenType_WHILE,
 }
 
 type Scanner struct {
+	vm      *VM
+	src     *Source
 	source  string
 	tokens  []*Token
 	start   int
 	current int
 	line    int
+
+	// 当前行起始位置，以及 current 之前当前行已经消费的 rune 数，用于计算列号
+	lineStart   int
+	column      int
+	startLine   int
+	startColumn int
 }
 
-func NewScanner(source string) *Scanner {
+func NewScanner(vm *VM, src *Source) *Scanner {
 	s := &Scanner{
-		source:  source,
+		vm:      vm,
+		src:     src,
+		source:  src.Text,
 		start:   0,
 		current: 0,
 		line:    1,
@@ -45,15 +65,23 @@ func (s *Scanner) scanTokens() []*Token {
 
 	for !s.isAtEnd() {
 		// We are at the beginning of the next lexeme.
-		s.start = s.current
+		s.begin()
 		s.scanToken()
 	}
 
-	s.tokens = append(s.tokens, NewToken(TokenType_EOF, "", nil, s.line))
+	s.begin()
+	s.tokens = append(s.tokens, s.makeToken(TokenType_EOF, nil))
 
 	return s.tokens
 }
 
+// begin 记录下一个 lexeme 的起始位置，列号按 rune 计算
+func (s *Scanner) begin() {
+	s.start = s.current
+	s.startLine = s.line
+	s.startColumn = s.column + 1
+}
+
 func (s *Scanner) isAtEnd() bool {
 	return s.current >= len(s.source)
 }
@@ -69,8 +97,14 @@ func (s *Scanner) scanToken() {
 		s.addToken(TokenType_LEFT_BRACE, nil)
 	case '}':
 		s.addToken(TokenType_RIGHT_BRACE, nil)
+	case '[':
+		s.addToken(TokenType_LEFT_BRACKET, nil)
+	case ']':
+		s.addToken(TokenType_RIGHT_BRACKET, nil)
 	case ',':
 		s.addToken(TokenType_COMMA, nil)
+	case ':':
+		s.addToken(TokenType_COLON, nil)
 	case '.':
 		s.addToken(TokenType_DOT, nil)
 	case '-':
@@ -81,6 +115,15 @@ func (s *Scanner) scanToken() {
 		s.addToken(TokenType_SEMICOLON, nil)
 	case '*':
 		s.addToken(TokenType_STAR, nil)
+	case '%':
+		s.addToken(TokenType_PERCENT, nil)
+	case '~':
+		// ~/ 是整数除法
+		if s.match('/') {
+			s.addToken(TokenType_TILDE_SLASH, nil)
+		} else {
+			s.error("Unexpected character.")
+		}
 	case '!':
 		var tokenType TokenType
 		if s.match('=') {
@@ -93,6 +136,8 @@ func (s *Scanner) scanToken() {
 		var tokenType TokenType
 		if s.match('=') {
 			tokenType = TokenType_EQUAL_EQUAL
+		} else if s.match('>') {
+			tokenType = TokenType_ARROW
 		} else {
 			tokenType = TokenType_EQUAL
 		}
@@ -123,120 +168,249 @@ func (s *Scanner) scanToken() {
 			s.addToken(TokenType_SLASH, nil)
 		}
 	case '\n':
-		s.line++
+		s.newLine()
 	case ' ', '\r', '\t':
 	// Ignore whitespace.
+	case '\uFEFF':
+		// 忽略文件开头的 BOM
+		if s.start != 0 {
+			s.error("Unexpected character.")
+		}
 	case '"':
-		s.string()
+		s.string(false)
 	default:
-		if s.isDigit(c) {
-			s.number()
+		if c == 'r' && s.peek() == '"' {
+			// r"..." 是不处理转义的原始字符串
+			s.advance()
+			s.string(true)
+		} else if s.isDigit(c) {
+			s.number(c)
 		} else if s.isAlpha(c) {
 			s.identifier()
+		} else if c == utf8.RuneError {
+			s.error("Invalid UTF-8 encoding.")
 		} else {
-			reportError(s.line, "Unexpected character.")
+			s.error("Unexpected character.")
 		}
 	}
 }
 
-func (s *Scanner) advance() uint8 {
-	s.current++
-	return s.source[s.current-1]
+// advance 消费并返回下一个 rune，非法的 UTF-8 字节返回 utf8.RuneError
+func (s *Scanner) advance() rune {
+	c, size := utf8.DecodeRuneInString(s.source[s.current:])
+	s.current += size
+	s.column++
+	return c
+}
+
+// newLine 在消费完一个换行符之后调用
+func (s *Scanner) newLine() {
+	s.line++
+	s.lineStart = s.current
+	s.column = 0
 }
 
-func (s *Scanner) peek() uint8 {
+func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return 0
 	}
-	return s.source[s.current]
+	c, _ := utf8.DecodeRuneInString(s.source[s.current:])
+	return c
 }
 
-func (s *Scanner) peekNext() uint8 {
-	if s.current+1 >= len(s.source) {
+func (s *Scanner) peekNext() rune {
+	if s.isAtEnd() {
 		return 0
 	}
-	return s.source[s.current+1]
+	_, size := utf8.DecodeRuneInString(s.source[s.current:])
+	if s.current+size >= len(s.source) {
+		return 0
+	}
+	c, _ := utf8.DecodeRuneInString(s.source[s.current+size:])
+	return c
 }
 
 func (s *Scanner) addToken(tokenType TokenType, literal interface{}) {
+	s.tokens = append(s.tokens, s.makeToken(tokenType, literal))
+}
+
+// makeToken 用当前扫描到的 lexeme 创建 token，并记录它在源码中的位置
+func (s *Scanner) makeToken(tokenType TokenType, literal interface{}) *Token {
 	text := s.source[s.start:s.current]
-	s.tokens = append(s.tokens, NewToken(tokenType, text, literal, s.line))
+	token := NewToken(tokenType, text, literal, s.startLine)
+	token.column = s.startColumn
+	token.offset = s.start
+	token.length = s.current - s.start
+	token.src = s.src
+	return token
+}
+
+func (s *Scanner) error(message string) {
+	s.vm.scanError(s.makeToken(TokenType_None, nil), message)
 }
 
-func (s *Scanner) match(expected uint8) bool {
+func (s *Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
-	if s.source[s.current] != expected {
+	if s.peek() != expected {
 		return false
 	}
 
-	s.current++
+	s.advance()
 	return true
 }
 
-func (s *Scanner) string() {
-	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
-		}
+// string 扫描字符串，开头的引号已经被消费。""" 开头的是可以跨行的三引号字符串，会去掉共同缩进；
+// raw 为 true 时不处理转义序列。
+func (s *Scanner) string(raw bool) {
+	triple := s.peek() == '"' && s.peekNext() == '"'
+	if triple {
+		s.advance()
 		s.advance()
 	}
 
+	contentStart := s.current
+	for !s.isAtEnd() && !s.closingQuote(triple) {
+		c := s.advance()
+		if c == '\n' {
+			s.newLine()
+		} else if c == '\\' && !raw && !s.isAtEnd() {
+			// 跳过被转义的字符，这样 \" 不会结束字符串
+			if s.advance() == '\n' {
+				s.newLine()
+			}
+		}
+	}
+
 	if s.isAtEnd() {
-		reportError(s.line, "Unterminated string.")
+		s.error("Unterminated string.")
 		return
 	}
 
+	content := s.source[contentStart:s.current]
+
 	// The closing ".
 	s.advance()
+	if triple {
+		s.advance()
+		s.advance()
+	}
 
-	// Trim the surrounding quotes.
-	value := s.source[s.start+1 : s.current-1]
-	value, err := strconv.Unquote(`"` + value + `"`)
-	if err != nil {
-		reportError(s.line, "Unexpected string.")
+	value := content
+	if triple {
+		value = dedent(content)
+	}
+	if !raw {
+		unescaped, err := unescape(value)
+		if err != nil {
+			// 去掉缩进后位置会变，在原文中重新定位出错的转义
+			if triple {
+				_, err = unescape(content)
+			}
+			s.errorAt(contentStart+err.index, err.length, err.message)
+		}
+		value = unescaped
 	}
 	s.addToken(TokenType_STRING, value)
 }
 
-func (s *Scanner) isDigit(c uint8) bool {
+func (s *Scanner) closingQuote(triple bool) bool {
+	if !triple {
+		return s.peek() == '"'
+	}
+	return strings.HasPrefix(s.source[s.current:], `"""`)
+}
+
+// errorAt 报告 lexeme 中间某一段的错误，offset 和 length 都以字节计
+func (s *Scanner) errorAt(offset int, length int, message string) {
+	lineStart := strings.LastIndexByte(s.source[:offset], '\n') + 1
+	token := NewToken(TokenType_None, s.source[offset:offset+length], nil, s.startLine+strings.Count(s.source[s.start:offset], "\n"))
+	token.column = utf8.RuneCountInString(s.source[lineStart:offset]) + 1
+	token.offset = offset
+	token.length = length
+	token.src = s.src
+	s.vm.scanError(token, message)
+}
+
+// isDigit 只接受 ASCII 数字，数字字面量不支持其他文字的数字
+func (s *Scanner) isDigit(c rune) bool {
 	return c >= '0' && c <= '9'
 }
 
-func (s *Scanner) isAlpha(c uint8) bool {
+// isAlpha 判断 c 能否作为标识符的开头：'_' 以及任何 Unicode 字母（L 类）和字母数字（Nl 类）
+func (s *Scanner) isAlpha(c rune) bool {
 	return (c >= 'a' && c <= 'z') ||
 		(c >= 'A' && c <= 'Z') ||
-		c == '_'
+		c == '_' ||
+		(c >= utf8.RuneSelf && (unicode.IsLetter(c) || unicode.Is(unicode.Nl, c)))
 }
 
-func (s *Scanner) isAlphaNumeric(c uint8) bool {
-	return s.isAlpha(c) || s.isDigit(c)
+// isAlphaNumeric 判断 c 能否出现在标识符开头之后：
+// 在 isAlpha 的基础上再加上十进制数字（Nd 类）、组合标记（Mn、Mc 类）和连接符（Pc 类）
+func (s *Scanner) isAlphaNumeric(c rune) bool {
+	return s.isAlpha(c) || s.isDigit(c) ||
+		(c >= utf8.RuneSelf && unicode.In(c, unicode.Nd, unicode.Mn, unicode.Mc, unicode.Pc))
 }
 
-func (s *Scanner) number() {
-	for s.isDigit(s.peek()) {
-		s.advance()
+// number 扫描数字字面量，first 是已经消费的第一个数字。
+// 支持 0x、0o、0b 前缀、小数、指数和 '_' 分隔符，格式错误时报告错误并按 0 继续。
+func (s *Scanner) number(first rune) {
+	base := 10
+	if first == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
 	}
 
-	// Look for a fractional part.
-	if s.peek() == '.' && s.isDigit(s.peekNext()) {
-		// Consume the "."
-		s.advance()
+	if base == 10 {
+		s.digits()
 
-		for s.isDigit(s.peek()) {
+		// Look for a fractional part.
+		if s.peek() == '.' && s.isDigit(s.peekNext()) {
+			// Consume the "."
 			s.advance()
+			s.digits()
 		}
 
+		if s.peek() == 'e' || s.peek() == 'E' {
+			s.advance()
+			if s.peek() == '+' || s.peek() == '-' {
+				s.advance()
+			}
+			s.digits()
+		}
+	} else {
+		// Consume the prefix.
+		s.advance()
 	}
 
-	text := s.source[s.start:s.current]
+	// 紧跟在数字后面的字母也算作字面量的一部分，这样 123abc、0xFG 可以整体报错
+	for s.isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
 
-	value, _ := strconv.ParseFloat(text, 64)
+	text := s.source[s.start:s.current]
+	value, message := parseNumber(text, base)
+	if message != "" {
+		s.error(message)
+	}
 
 	s.addToken(TokenType_NUMBER, value)
 }
 
+// digits 消费连续的数字和分隔符
+func (s *Scanner) digits() {
+	for s.isDigit(s.peek()) || s.peek() == '_' {
+		s.advance()
+	}
+}
+
 func (s *Scanner) identifier() {
 	for s.isAlphaNumeric(s.peek()) {
 		s.advance()
@@ -0,0 +1,85 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Source 是一段被执行的源码，Name 为文件名，不是来自文件时为空
+type Source struct {
+	Name string
+	Text string
+}
+
+// lineText 返回第 line 行（从 1 开始）的内容，不含换行符
+func (s *Source) lineText(line int) (string, bool) {
+	text := s.Text
+	for current := 1; current < line; current++ {
+		index := strings.IndexByte(text, '\n')
+		if index < 0 {
+			return "", false
+		}
+		text = text[index+1:]
+	}
+	if index := strings.IndexByte(text, '\n'); index >= 0 {
+		text = text[:index]
+	}
+	return strings.TrimRight(text, "\r"), true
+}
+
+// renderSnippet 渲染 line 行的源码，并在从 column 列（按 rune 计）开始、
+// 源码中 offset 处 length 个字节对应的字符下面画出 ^，
+// 例如：
+//
+//	 --> test.lox:2:9
+//	2 | print a @;
+//	  |         ^
+func renderSnippet(src *Source, line int, column int, offset int, length int) string {
+	if src == nil || line <= 0 || column <= 0 {
+		return ""
+	}
+	text, ok := src.lineText(line)
+	if !ok {
+		return ""
+	}
+
+	location := fmt.Sprintf("%d:%d", line, column)
+	if src.Name != "" {
+		location = src.Name + ":" + location
+	}
+	gutter := fmt.Sprintf("%d", line)
+	blank := strings.Repeat(" ", len(gutter))
+
+	// 缩进保留原来的 tab，这样 ^ 才能和源码对齐
+	runes := []rune(text)
+	start := column - 1
+	if start > len(runes) {
+		start = len(runes)
+	}
+	var indent strings.Builder
+	for _, c := range runes[:start] {
+		if c == '\t' {
+			indent.WriteByte('\t')
+		} else {
+			indent.WriteByte(' ')
+		}
+	}
+	width := 0
+	if offset >= 0 && offset+length <= len(src.Text) {
+		width = utf8.RuneCountInString(src.Text[offset : offset+length])
+	}
+	// 跨行的 token 只标记到行尾
+	if start+width > len(runes) {
+		width = len(runes) - start
+	}
+	if width < 1 {
+		width = 1
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s--> %s\n", blank, location))
+	b.WriteString(fmt.Sprintf("%s | %s\n", gutter, text))
+	b.WriteString(fmt.Sprintf("%s | %s%s\n", blank, indent.String(), strings.Repeat("^", width)))
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// callFrame 记录一次正在进行的函数调用
+type callFrame struct {
+	callee   LoxCallable
+	callSite *Token
+}
+
+// StackFrame 是 RuntimeError 调用栈中的一项，Line 是该帧出错或发起下一层调用时所在的行
+type StackFrame struct {
+	Function string
+	Class    string
+	Line     int
+}
+
+func (f StackFrame) String() string {
+	if f.Function == "" {
+		return fmt.Sprintf("[line %d] in script", f.Line)
+	}
+	if f.Class != "" {
+		return fmt.Sprintf("[line %d] in %s.%s()", f.Line, f.Class, f.Function)
+	}
+	return fmt.Sprintf("[line %d] in %s()", f.Line, f.Function)
+}
+
+// 调用栈太深时只输出两端的帧
+const stackTraceHead = 10
+const stackTraceTail = 5
+
+func (i *Interpreter) pushFrame(callee LoxCallable, callSite *Token) {
+	if i.maxCallDepth > 0 && len(i.frames) >= i.maxCallDepth {
+		panic(NewRuntimeError(callSite, "Stack overflow."))
+	}
+	i.frames = append(i.frames, callFrame{callee: callee, callSite: callSite})
+}
+
+// popFrame 必须通过 defer 调用。运行时错误第一次经过时，在帧被弹出之前记录调用栈
+func (i *Interpreter) popFrame() {
+	if r := recover(); r != nil {
+		if err, ok := r.(*RuntimeError); ok && err.Trace == nil {
+			err.Trace = i.stackTrace(err.Token)
+		}
+		i.frames = i.frames[:len(i.frames)-1]
+		panic(r)
+	}
+	i.frames = i.frames[:len(i.frames)-1]
+}
+
+// stackTrace 从最内层开始列出当前的调用栈，token 是最内层正在执行的位置
+func (i *Interpreter) stackTrace(token *Token) []StackFrame {
+	trace := make([]StackFrame, 0, len(i.frames)+1)
+	line := token.line
+	for index := len(i.frames) - 1; index >= 0; index-- {
+		frame := i.frames[index]
+		class, function := frameName(frame.callee)
+		trace = append(trace, StackFrame{Function: function, Class: class, Line: line})
+		line = frame.callSite.line
+	}
+	// 从 Go 直接调用时没有脚本层
+	if line > 0 || len(i.frames) == 0 {
+		trace = append(trace, StackFrame{Line: line})
+	}
+	return trace
+}
+
+func frameName(callee LoxCallable) (class string, function string) {
+	switch c := callee.(type) {
+	case *LoxFunction:
+		return c.className, c.name()
+	case *LoxClass:
+		return "", c.name
+	case *NativeFunction:
+		return "", c.name
+	case *NativeClass:
+		return "", c.name
+	case *builtinMethod:
+		return c.class, c.name
+	}
+	return "", callee.String()
+}
+
+// StackTrace 按 clox 的格式输出调用栈，每帧一行
+func (e *RuntimeError) StackTrace() string {
+	return formatStackTrace(e.Trace)
+}
+
+func formatStackTrace(trace []StackFrame) string {
+	var b strings.Builder
+	for index, frame := range trace {
+		if len(trace) > stackTraceHead+stackTraceTail && index == stackTraceHead {
+			b.WriteString(fmt.Sprintf("... %d more frames ...\n", len(trace)-stackTraceHead-stackTraceTail))
+		}
+		if len(trace) > stackTraceHead+stackTraceTail && index >= stackTraceHead && index < len(trace)-stackTraceTail {
+			continue
+		}
+		b.WriteString(frame.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
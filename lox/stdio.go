@@ -0,0 +1,50 @@
+package lox
+
+import (
+	"bufio"
+	"io"
+	"os"
+)
+
+// WithStdout 设置 print 和 REPL 提示符的输出位置，默认是 os.Stdout
+func WithStdout(w io.Writer) Option {
+	return func(vm *VM) {
+		vm.interpreter.stdout = w
+	}
+}
+
+// WithStderr 设置错误输出位置。没有通过 WithErrorReporter 指定报告方式时，错误会以纯文本写到这里
+func WithStderr(w io.Writer) Option {
+	return func(vm *VM) {
+		vm.interpreter.stderr = w
+	}
+}
+
+// WithStdin 设置 REPL 和读取输入的原生函数使用的输入，默认是 os.Stdin
+func WithStdin(r io.Reader) Option {
+	return func(vm *VM) {
+		vm.interpreter.stdin = bufio.NewReader(r)
+	}
+}
+
+func (i *Interpreter) Stdout() io.Writer {
+	if i.stdout == nil {
+		return os.Stdout
+	}
+	return i.stdout
+}
+
+func (i *Interpreter) Stderr() io.Writer {
+	if i.stderr == nil {
+		return os.Stderr
+	}
+	return i.stderr
+}
+
+// Stdin 返回带缓冲的输入，同一个解释器中的所有读取共享这个缓冲
+func (i *Interpreter) Stdin() *bufio.Reader {
+	if i.stdin == nil {
+		i.stdin = bufio.NewReader(os.Stdin)
+	}
+	return i.stdin
+}
@@ -14,6 +14,19 @@ func NewBlockStmt(statements []Stmt)*BlockStmt{
 	return b
 }
 
+type BreakStmt struct{
+	keyword *Token
+	label *Token
+}
+
+func NewBreakStmt(keyword *Token, label *Token)*BreakStmt{
+	b := &BreakStmt{
+		keyword: keyword,
+		label: label,
+	}
+	return b
+}
+
 type ClassStmt struct{
 	name *Token
 	superclass *VariableExpr
@@ -29,6 +42,34 @@ func NewClassStmt(name *Token, superclass *VariableExpr, methods []*FunctionStmt
 	return c
 }
 
+type ContinueStmt struct{
+	keyword *Token
+	label *Token
+}
+
+func NewContinueStmt(keyword *Token, label *Token)*ContinueStmt{
+	c := &ContinueStmt{
+		keyword: keyword,
+		label: label,
+	}
+	return c
+}
+
+type ExportStmt struct{
+	keyword *Token
+	declaration Stmt
+	names []*Token
+}
+
+func NewExportStmt(keyword *Token, declaration Stmt, names []*Token)*ExportStmt{
+	e := &ExportStmt{
+		keyword: keyword,
+		declaration: declaration,
+		names: names,
+	}
+	return e
+}
+
 type ExpressionStmt struct{
 	expression Expr
 }
@@ -70,6 +111,25 @@ func NewIfStmt(condition Expr, thenBranch Stmt, elseBranch Stmt)*IfStmt{
 	return i
 }
 
+type ImportStmt struct{
+	keyword *Token
+	path *Token
+	alias *Token
+	names []*Token
+	aliases []*Token
+}
+
+func NewImportStmt(keyword *Token, path *Token, alias *Token, names []*Token, aliases []*Token)*ImportStmt{
+	i := &ImportStmt{
+		keyword: keyword,
+		path: path,
+		alias: alias,
+		names: names,
+		aliases: aliases,
+	}
+	return i
+}
+
 type PrintStmt struct{
 	expression Expr
 }
@@ -94,6 +154,38 @@ func NewReturnStmt(keyword *Token, value Expr)*ReturnStmt{
 	return r
 }
 
+type ThrowStmt struct{
+	keyword *Token
+	value Expr
+}
+
+func NewThrowStmt(keyword *Token, value Expr)*ThrowStmt{
+	t := &ThrowStmt{
+		keyword: keyword,
+		value: value,
+	}
+	return t
+}
+
+type TryStmt struct{
+	keyword *Token
+	body []Stmt
+	catchName *Token
+	catchBody []Stmt
+	finallyBody []Stmt
+}
+
+func NewTryStmt(keyword *Token, body []Stmt, catchName *Token, catchBody []Stmt, finallyBody []Stmt)*TryStmt{
+	t := &TryStmt{
+		keyword: keyword,
+		body: body,
+		catchName: catchName,
+		catchBody: catchBody,
+		finallyBody: finallyBody,
+	}
+	return t
+}
+
 type VarStmt struct{
 	name *Token
 	initializer Expr
@@ -108,26 +200,38 @@ func NewVarStmt(name *Token, initializer Expr)*VarStmt{
 }
 
 type WhileStmt struct{
+	keyword *Token
 	condition Expr
 	body Stmt
+	increment Expr
+	label *Token
 }
 
-func NewWhileStmt(condition Expr, body Stmt)*WhileStmt{
+func NewWhileStmt(keyword *Token, condition Expr, body Stmt, increment Expr, label *Token)*WhileStmt{
 	w := &WhileStmt{
+		keyword: keyword,
 		condition: condition,
 		body: body,
+		increment: increment,
+		label: label,
 	}
 	return w
 }
 
 type StmtVisitor interface{
 	VisitBlockStmt(blockstmt *BlockStmt)
+	VisitBreakStmt(breakstmt *BreakStmt)
 	VisitClassStmt(classstmt *ClassStmt)
+	VisitContinueStmt(continuestmt *ContinueStmt)
+	VisitExportStmt(exportstmt *ExportStmt)
 	VisitExpressionStmt(expressionstmt *ExpressionStmt)
 	VisitFunctionStmt(functionstmt *FunctionStmt)
 	VisitIfStmt(ifstmt *IfStmt)
+	VisitImportStmt(importstmt *ImportStmt)
 	VisitPrintStmt(printstmt *PrintStmt)
 	VisitReturnStmt(returnstmt *ReturnStmt)
+	VisitThrowStmt(throwstmt *ThrowStmt)
+	VisitTryStmt(trystmt *TryStmt)
 	VisitVarStmt(varstmt *VarStmt)
 	VisitWhileStmt(whilestmt *WhileStmt)
 }
@@ -136,18 +240,30 @@ func VisitorStmt(v StmtVisitor,s Stmt){
 	switch s.(type){
 	case *BlockStmt:
 		v.VisitBlockStmt(s.(*BlockStmt))
+	case *BreakStmt:
+		v.VisitBreakStmt(s.(*BreakStmt))
 	case *ClassStmt:
 		v.VisitClassStmt(s.(*ClassStmt))
+	case *ContinueStmt:
+		v.VisitContinueStmt(s.(*ContinueStmt))
+	case *ExportStmt:
+		v.VisitExportStmt(s.(*ExportStmt))
 	case *ExpressionStmt:
 		v.VisitExpressionStmt(s.(*ExpressionStmt))
 	case *FunctionStmt:
 		v.VisitFunctionStmt(s.(*FunctionStmt))
 	case *IfStmt:
 		v.VisitIfStmt(s.(*IfStmt))
+	case *ImportStmt:
+		v.VisitImportStmt(s.(*ImportStmt))
 	case *PrintStmt:
 		v.VisitPrintStmt(s.(*PrintStmt))
 	case *ReturnStmt:
 		v.VisitReturnStmt(s.(*ReturnStmt))
+	case *ThrowStmt:
+		v.VisitThrowStmt(s.(*ThrowStmt))
+	case *TryStmt:
+		v.VisitTryStmt(s.(*TryStmt))
 	case *VarStmt:
 		v.VisitVarStmt(s.(*VarStmt))
 	case *WhileStmt:
@@ -157,12 +273,18 @@ func VisitorStmt(v StmtVisitor,s Stmt){
 
 type StmtVisitorWithVal[T any] interface{
 	VisitBlockStmt(blockstmt *BlockStmt) T
+	VisitBreakStmt(breakstmt *BreakStmt) T
 	VisitClassStmt(classstmt *ClassStmt) T
+	VisitContinueStmt(continuestmt *ContinueStmt) T
+	VisitExportStmt(exportstmt *ExportStmt) T
 	VisitExpressionStmt(expressionstmt *ExpressionStmt) T
 	VisitFunctionStmt(functionstmt *FunctionStmt) T
 	VisitIfStmt(ifstmt *IfStmt) T
+	VisitImportStmt(importstmt *ImportStmt) T
 	VisitPrintStmt(printstmt *PrintStmt) T
 	VisitReturnStmt(returnstmt *ReturnStmt) T
+	VisitThrowStmt(throwstmt *ThrowStmt) T
+	VisitTryStmt(trystmt *TryStmt) T
 	VisitVarStmt(varstmt *VarStmt) T
 	VisitWhileStmt(whilestmt *WhileStmt) T
 }
@@ -171,18 +293,30 @@ func VisitorStmtWithVal[T any](v StmtVisitorWithVal[T],s Stmt) T{
 	switch s.(type){
 	case *BlockStmt:
 		return v.VisitBlockStmt(s.(*BlockStmt))
+	case *BreakStmt:
+		return v.VisitBreakStmt(s.(*BreakStmt))
 	case *ClassStmt:
 		return v.VisitClassStmt(s.(*ClassStmt))
+	case *ContinueStmt:
+		return v.VisitContinueStmt(s.(*ContinueStmt))
+	case *ExportStmt:
+		return v.VisitExportStmt(s.(*ExportStmt))
 	case *ExpressionStmt:
 		return v.VisitExpressionStmt(s.(*ExpressionStmt))
 	case *FunctionStmt:
 		return v.VisitFunctionStmt(s.(*FunctionStmt))
 	case *IfStmt:
 		return v.VisitIfStmt(s.(*IfStmt))
+	case *ImportStmt:
+		return v.VisitImportStmt(s.(*ImportStmt))
 	case *PrintStmt:
 		return v.VisitPrintStmt(s.(*PrintStmt))
 	case *ReturnStmt:
 		return v.VisitReturnStmt(s.(*ReturnStmt))
+	case *ThrowStmt:
+		return v.VisitThrowStmt(s.(*ThrowStmt))
+	case *TryStmt:
+		return v.VisitTryStmt(s.(*TryStmt))
 	case *VarStmt:
 		return v.VisitVarStmt(s.(*VarStmt))
 	case *WhileStmt:
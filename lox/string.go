@@ -0,0 +1,211 @@
+package lox
+
+import (
+	"fmt"
+	"lox_go/util"
+	"strings"
+	"unicode/utf8"
+)
+
+// 字符串的长度和下标都按 Unicode 码点计算，s[i] 得到只有一个字符的字符串
+
+// 字符串最多的字节数，拼接、replace、join、repeat 的结果超过时报错，避免占满内存
+const maxStringLength = 1 << 28
+
+// checkStringLength 检查 operation 生成的字符串的长度 length 是否超过 maxStringLength
+func checkStringLength(token *Token, length int64, operation string) {
+	if length > maxStringLength {
+		panic(NewRuntimeError(token, "Result of "+operation+" is too long."))
+	}
+}
+
+// stringIndex 检查下标并转换成码点位置，allowEnd 为 true 时允许下标等于长度（用于 substring）
+func stringIndex(token *Token, runes []rune, value interface{}, allowEnd bool) int {
+	kind := numberKind(value)
+	n, ok := toInt(value)
+	if (kind != kindInt && kind != kindBigInt) || !ok {
+		panic(NewRuntimeError(token, "String index must be an integer."))
+	}
+	limit := int64(len(runes))
+	if allowEnd {
+		limit++
+	}
+	if n < 0 || n >= limit {
+		panic(NewRuntimeError(token, fmt.Sprintf("String index %d is out of range for length %d.", n, len(runes))))
+	}
+	return int(n)
+}
+
+func stringGet(token *Token, s string, index interface{}) interface{} {
+	runes := []rune(s)
+	return string(runes[stringIndex(token, runes, index, false)])
+}
+
+// stringMethod 查找字符串 s 的方法
+func stringMethod(s string, name *Token) interface{} {
+	if method, ok := stringMethods[name.lexeme]; ok {
+		return &builtinMethod{
+			class:    "string",
+			name:     name.lexeme,
+			minArity: method.minArity,
+			maxArity: method.maxArity,
+			fn: func(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+				return method.fn(s, paren, arguments)
+			},
+		}
+	}
+	panic(NewRuntimeError(name, "Undefined property '"+name.lexeme+"'."))
+}
+
+var stringMethods = map[string]struct {
+	minArity int
+	maxArity int
+	fn       func(s string, paren *Token, arguments []interface{}) interface{}
+}{
+	"len":        {0, 0, stringLen},
+	"substring":  {1, 2, stringSubstring},
+	"indexOf":    {1, 2, stringIndexOf},
+	"split":      {1, 1, stringSplit},
+	"join":       {1, 1, stringJoin},
+	"replace":    {2, 2, stringReplace},
+	"trim":       {0, 0, stringTrim},
+	"upper":      {0, 0, stringUpper},
+	"lower":      {0, 0, stringLower},
+	"startsWith": {1, 1, stringStartsWith},
+	"endsWith":   {1, 1, stringEndsWith},
+	"contains":   {1, 1, stringContains},
+	"repeat":     {1, 1, stringRepeat},
+}
+
+// stringArgument 取出第 index 个字符串参数
+func stringArgument(paren *Token, method string, arguments []interface{}, index int) string {
+	s, ok := arguments[index].(string)
+	if !ok {
+		panic(NewRuntimeError(paren, fmt.Sprintf("Argument %d of '%s' must be a string.", index+1, method)))
+	}
+	return s
+}
+
+func stringLen(s string, paren *Token, arguments []interface{}) interface{} {
+	return int64(utf8.RuneCountInString(s))
+}
+
+// stringSubstring 返回 [start, end) 之间的字符，省略 end 时到字符串末尾
+func stringSubstring(s string, paren *Token, arguments []interface{}) interface{} {
+	runes := []rune(s)
+	start := stringIndex(paren, runes, arguments[0], true)
+	end := len(runes)
+	if len(arguments) > 1 {
+		end = stringIndex(paren, runes, arguments[1], true)
+	}
+	if start > end {
+		panic(NewRuntimeError(paren, fmt.Sprintf("Substring start %d is after end %d.", start, end)))
+	}
+	return string(runes[start:end])
+}
+
+// stringIndexOf 返回 sub 第一次出现的位置，找不到时返回 -1；from 指定开始查找的位置
+func stringIndexOf(s string, paren *Token, arguments []interface{}) interface{} {
+	sub := stringArgument(paren, "indexOf", arguments, 0)
+	runes := []rune(s)
+	from := 0
+	if len(arguments) > 1 {
+		from = stringIndex(paren, runes, arguments[1], true)
+	}
+	rest := string(runes[from:])
+	offset := strings.Index(rest, sub)
+	if offset < 0 {
+		return int64(-1)
+	}
+	return int64(from + utf8.RuneCountInString(rest[:offset]))
+}
+
+// stringSplit 按 separator 拆分成字符串列表，separator 为空字符串时拆成单个字符
+func stringSplit(s string, paren *Token, arguments []interface{}) interface{} {
+	parts := strings.Split(s, stringArgument(paren, "split", arguments, 0))
+	elements := make([]interface{}, len(parts))
+	for index, part := range parts {
+		elements[index] = part
+	}
+	return NewLoxList(elements)
+}
+
+// stringJoin 用 s 连接列表中的元素，不是字符串的元素按 print 的格式转换
+func stringJoin(s string, paren *Token, arguments []interface{}) interface{} {
+	list, ok := arguments[0].(*LoxList)
+	if !ok {
+		panic(NewRuntimeError(paren, "Argument 1 of 'join' must be a list."))
+	}
+	parts := make([]string, len(list.elements))
+	length := int64(0)
+	for index, element := range list.elements {
+		parts[index] = util.GetInterfaceToString(element)
+		length += int64(len(parts[index]))
+		if index > 0 {
+			length += int64(len(s))
+		}
+		checkStringLength(paren, length, "join")
+	}
+	return strings.Join(parts, s)
+}
+
+// stringReplace 把所有的 old 替换成 new
+func stringReplace(s string, paren *Token, arguments []interface{}) interface{} {
+	old := stringArgument(paren, "replace", arguments, 0)
+	replacement := stringArgument(paren, "replace", arguments, 1)
+	count := int64(strings.Count(s, old))
+	checkStringLength(paren, int64(len(s))+count*int64(len(replacement)-len(old)), "replace")
+	return strings.ReplaceAll(s, old, replacement)
+}
+
+func stringTrim(s string, paren *Token, arguments []interface{}) interface{} {
+	return strings.TrimSpace(s)
+}
+
+func stringUpper(s string, paren *Token, arguments []interface{}) interface{} {
+	return strings.ToUpper(s)
+}
+
+func stringLower(s string, paren *Token, arguments []interface{}) interface{} {
+	return strings.ToLower(s)
+}
+
+func stringStartsWith(s string, paren *Token, arguments []interface{}) interface{} {
+	return strings.HasPrefix(s, stringArgument(paren, "startsWith", arguments, 0))
+}
+
+func stringEndsWith(s string, paren *Token, arguments []interface{}) interface{} {
+	return strings.HasSuffix(s, stringArgument(paren, "endsWith", arguments, 0))
+}
+
+func stringContains(s string, paren *Token, arguments []interface{}) interface{} {
+	return strings.Contains(s, stringArgument(paren, "contains", arguments, 0))
+}
+
+func stringRepeat(s string, paren *Token, arguments []interface{}) interface{} {
+	kind := numberKind(arguments[0])
+	n, ok := toInt(arguments[0])
+	if kind != kindInt || !ok {
+		panic(NewRuntimeError(paren, "Argument 1 of 'repeat' must be an integer."))
+	}
+	if n < 0 {
+		panic(NewRuntimeError(paren, "Repeat count can't be negative."))
+	}
+	if len(s) > 0 && n > maxStringLength/int64(len(s)) {
+		panic(NewRuntimeError(paren, "Result of repeat is too long."))
+	}
+	return strings.Repeat(s, int(n))
+}
+
+// nativeLen 实现内置函数 len，返回字符串、列表或 map 的长度
+func nativeLen(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return int64(utf8.RuneCountInString(v)), nil
+	case *LoxList:
+		return int64(len(v.elements)), nil
+	case *LoxMap:
+		return int64(v.Len()), nil
+	}
+	return nil, fmt.Errorf("Can't get the length of %s.", formatElement(value, map[interface{}]bool{}))
+}
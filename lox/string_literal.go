@@ -0,0 +1,117 @@
+package lox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// escapeError 记录转义序列错误在字符串内容中的字节位置
+type escapeError struct {
+	index   int
+	length  int
+	message string
+}
+
+// unescape 处理字符串中的转义序列：\n \t \r \0 \\ \" \' 和 \u{XXXX}（1 到 6 位十六进制）
+func unescape(text string) (string, *escapeError) {
+	if strings.IndexByte(text, '\\') < 0 {
+		return text, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 >= len(text) {
+			return b.String(), &escapeError{index: i, length: 1, message: "Unterminated escape sequence."}
+		}
+
+		start := i
+		i++
+		switch text[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case '0':
+			b.WriteByte(0)
+		case '\\', '"', '\'':
+			b.WriteByte(text[i])
+		case 'u':
+			end := strings.IndexByte(text[i:], '}')
+			if i+1 >= len(text) || text[i+1] != '{' || end < 0 {
+				return b.String(), &escapeError{index: start, length: 2, message: "Expect '{' and '}' around unicode escape."}
+			}
+			end += i
+			digits := text[i+2 : end]
+			value, err := strconv.ParseUint(digits, 16, 32)
+			if err != nil || len(digits) == 0 || len(digits) > 6 || !utf8.ValidRune(rune(value)) {
+				return b.String(), &escapeError{index: start, length: end + 1 - start, message: "Invalid unicode escape '" + text[start:end+1] + "'."}
+			}
+			b.WriteRune(rune(value))
+			i = end
+		default:
+			_, size := utf8.DecodeRuneInString(text[i:])
+			return b.String(), &escapeError{index: start, length: 1 + size, message: fmt.Sprintf("Invalid escape sequence '%s'.", text[start:i+size])}
+		}
+	}
+	return b.String(), nil
+}
+
+// dedent 处理三引号字符串的缩进：
+// 开头的 """ 后面只有空白时，第一行被去掉；结束的 """ 单独一行时，最后一行也被去掉；
+// 剩下的非空行去掉共同的前导空白（结束行的缩进也参与计算），只含空白的行变成空行。
+func dedent(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return text
+	}
+
+	if strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	var indent *string
+	last := lines[len(lines)-1]
+	if strings.TrimSpace(last) == "" {
+		indent = &last
+		lines = lines[:len(lines)-1]
+	}
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		prefix := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if indent == nil {
+			indent = &prefix
+		} else {
+			common := commonPrefix(*indent, prefix)
+			indent = &common
+		}
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			lines[i] = ""
+		} else if indent != nil {
+			lines[i] = strings.TrimPrefix(line, commonPrefix(*indent, line))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func commonPrefix(a string, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
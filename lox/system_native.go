@@ -0,0 +1,181 @@
+package lox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ExitError 表示脚本调用了 os.exit。它不能被 try/catch 捕获，VM 也不会把它当作错误报告，
+// 由宿主决定怎么处理退出码
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// WithSystemAccess 控制脚本能否使用 fs 和 os 模块，默认不允许。命令行等信任脚本的宿主才应该打开它
+func WithSystemAccess(enabled bool) Option {
+	return func(vm *VM) {
+		vm.systemAccess = enabled
+	}
+}
+
+// WithArgs 设置脚本通过 os.argv 拿到的命令行参数
+func WithArgs(args ...string) Option {
+	return func(vm *VM) {
+		vm.args = args
+	}
+}
+
+// defineSystemModules 注册读写文件的 fs 模块和访问进程环境的 os 模块
+func (i *Interpreter) defineSystemModules(args []string) {
+	_ = i.DefineModule("fs", map[string]interface{}{
+		"readFile":   fsReadFile,
+		"writeFile":  fsWriteFile,
+		"appendFile": fsAppendFile,
+		"exists":     fsExists,
+		"listDir":    fsListDir,
+		"mkdir":      fsMkdir,
+		"remove":     fsRemove,
+	})
+
+	argv := make([]interface{}, len(args))
+	for index, arg := range args {
+		argv[index] = arg
+	}
+	env := newScriptEnv()
+	_ = i.DefineModule("os", map[string]interface{}{
+		"argv":   NewLoxList(argv),
+		"getenv": env.getenv,
+		"setenv": env.setenv,
+		"exit": &builtinMethod{
+			class:    "os",
+			name:     "exit",
+			minArity: 0,
+			maxArity: 1,
+			fn:       osExit,
+		},
+	})
+}
+
+// fsError 把 Go 的文件错误转换成脚本中的错误信息，去掉重复的路径和操作名
+func fsError(action string, path string, err error) error {
+	var pathError *os.PathError
+	if errors.As(err, &pathError) {
+		err = pathError.Err
+	}
+	return fmt.Errorf("Can't %s '%s': %v.", action, path, err)
+}
+
+func fsReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fsError("read", path, err)
+	}
+	return string(data), nil
+}
+
+func fsWriteFile(path string, text string) error {
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		return fsError("write", path, err)
+	}
+	return nil
+}
+
+func fsAppendFile(path string, text string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fsError("append to", path, err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		return fsError("append to", path, err)
+	}
+	return nil
+}
+
+func fsExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// fsListDir 返回目录中的文件名列表，按名字排序
+func fsListDir(path string) (*LoxList, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fsError("list", path, err)
+	}
+	names := make([]string, len(entries))
+	for index, entry := range entries {
+		names[index] = entry.Name()
+	}
+	sort.Strings(names)
+
+	elements := make([]interface{}, len(names))
+	for index, name := range names {
+		elements[index] = name
+	}
+	return NewLoxList(elements), nil
+}
+
+// fsMkdir 创建目录以及不存在的上级目录
+func fsMkdir(path string) error {
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return fsError("create directory", path, err)
+	}
+	return nil
+}
+
+// fsRemove 删除文件或空目录
+func fsRemove(path string) error {
+	if err := os.Remove(path); err != nil {
+		return fsError("remove", path, err)
+	}
+	return nil
+}
+
+// scriptEnv 是脚本看到的环境变量。os.setenv 只修改这个 VM 里的副本，不影响宿主进程和其他 VM
+type scriptEnv struct {
+	overrides map[string]string
+}
+
+func newScriptEnv() *scriptEnv {
+	e := &scriptEnv{
+		overrides: make(map[string]string),
+	}
+	return e
+}
+
+// getenv 返回环境变量的值，没有设置时返回 nil
+func (e *scriptEnv) getenv(name string) interface{} {
+	if value, ok := e.overrides[name]; ok {
+		return value
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value
+	}
+	return nil
+}
+
+func (e *scriptEnv) setenv(name string, value string) {
+	e.overrides[name] = value
+}
+
+// osExit 实现 os.exit(code)，省略 code 时为 0。脚本会立即停止，但 finally 仍然会执行
+func osExit(i *Interpreter, paren *Token, arguments []interface{}) interface{} {
+	code := int64(0)
+	if len(arguments) > 0 {
+		n, ok := toInt(arguments[0])
+		if numberKind(arguments[0]) != kindInt || !ok || n < 0 || n > 255 {
+			panic(NewRuntimeError(paren, "Exit code must be an integer between 0 and 255."))
+		}
+		code = n
+	}
+	err := NewRuntimeError(paren, fmt.Sprintf("Exit with code %d.", code))
+	err.Err = &ExitError{Code: int(code)}
+	panic(err)
+}
@@ -10,13 +10,17 @@ const (
 	TokenType_RIGHT_PAREN
 	TokenType_LEFT_BRACE
 	TokenType_RIGHT_BRACE
+	TokenType_LEFT_BRACKET
+	TokenType_RIGHT_BRACKET
 	TokenType_COMMA
+	TokenType_COLON
 	TokenType_DOT
 	TokenType_MINUS
 	TokenType_PLUS
 	TokenType_SEMICOLON
 	TokenType_SLASH
 	TokenType_STAR
+	TokenType_PERCENT
 
 	// One or two character tokens.
 	TokenType_BANG
@@ -27,6 +31,8 @@ const (
 	TokenType_GREATER_EQUAL
 	TokenType_LESS
 	TokenType_LESS_EQUAL
+	TokenType_TILDE_SLASH
+	TokenType_ARROW
 
 	// Literals.
 	TokenType_IDENTIFIER
@@ -35,19 +41,27 @@ const (
 
 	// Keywords.
 	TokenType_AND
+	TokenType_BREAK
+	TokenType_CATCH
 	TokenType_CLASS
+	TokenType_CONTINUE
 	TokenType_ELSE
+	TokenType_EXPORT
 	TokenType_FALSE
+	TokenType_FINALLY
 	TokenType_FUN
 	TokenType_FOR
 	TokenType_IF
+	TokenType_IMPORT
 	TokenType_NIL
 	TokenType_OR
 	TokenType_PRINT
 	TokenType_RETURN
 	TokenType_SUPER
 	TokenType_THIS
+	TokenType_THROW
 	TokenType_TRUE
+	TokenType_TRY
 	TokenType_VAR
 	TokenType_WHILE
 
@@ -59,6 +73,12 @@ type Token struct {
 	lexeme    string
 	literal   interface{}
 	line      int
+	column    int
+
+	// 在源码中的字节偏移和长度，以及所属的源码，用于精确定位错误
+	offset int
+	length int
+	src    *Source
 }
 
 func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int) *Token {
@@ -70,3 +90,38 @@ func NewToken(tokenType TokenType, lexeme string, literal interface{}, line int)
 	}
 	return t
 }
+
+func (t *Token) Type() TokenType {
+	return t.tokenType
+}
+
+func (t *Token) Lexeme() string {
+	return t.lexeme
+}
+
+func (t *Token) Line() int {
+	return t.line
+}
+
+// Column 从 1 开始计数，0 表示未知
+func (t *Token) Column() int {
+	return t.column
+}
+
+// Offset 是 token 在源码中的起始字节偏移
+func (t *Token) Offset() int {
+	return t.offset
+}
+
+// Length 是 token 在源码中占的字节数
+func (t *Token) Length() int {
+	return t.length
+}
+
+// File 返回 token 所在的文件名，不是来自文件时为空
+func (t *Token) File() string {
+	if t.src == nil {
+		return ""
+	}
+	return t.src.Name
+}
@@ -0,0 +1,140 @@
+package lox
+
+import (
+	"math"
+	"math/big"
+	"reflect"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	bigIntType  = reflect.TypeOf((*big.Int)(nil))
+	decimalType = reflect.TypeOf((*Decimal)(nil))
+)
+
+// fromLoxValue 把 Lox 值转换成 Go 类型 t 的值，转换失败时返回 false
+func fromLoxValue(value interface{}, t reflect.Type) (reflect.Value, bool) {
+	// 原生实例传回 Go 时还原成背后的 Go 值
+	switch v := value.(type) {
+	case *NativeInstance:
+		if v.value.Type().AssignableTo(t) {
+			return v.value, true
+		}
+	case *LoxInstance:
+		if v.native != nil && v.native.value.Type().AssignableTo(t) {
+			return v.native.value, true
+		}
+	}
+
+	// 整数可以传给 *big.Int 参数，整数和大整数可以传给 *Decimal 参数
+	switch t {
+	case bigIntType:
+		if n, ok := toBigInt(value); ok {
+			return reflect.ValueOf(n), true
+		}
+	case decimalType:
+		if d, ok := toDecimal(value); ok {
+			return reflect.ValueOf(d), true
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if f, ok := toFloat(value); ok {
+			return reflect.ValueOf(f).Convert(t), true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// 值为整数的浮点数也可以传给整数参数，但不能超出目标类型的范围
+		if n, ok := toInt(value); ok && !reflect.Zero(t).OverflowInt(n) {
+			return reflect.ValueOf(n).Convert(t), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := toInt(value); ok && n >= 0 && !reflect.Zero(t).OverflowUint(uint64(n)) {
+			return reflect.ValueOf(uint64(n)).Convert(t), true
+		}
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			return reflect.ValueOf(s).Convert(t), true
+		}
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			return reflect.ValueOf(b).Convert(t), true
+		}
+	case reflect.Interface:
+		if value == nil {
+			return reflect.Zero(t), true
+		}
+	}
+
+	if value != nil && reflect.TypeOf(value).AssignableTo(t) {
+		return reflect.ValueOf(value), true
+	}
+	return reflect.Value{}, false
+}
+
+// toLoxValue 把 Go 值转换成 Lox 值，整数转换成 int64，浮点数转换成 float64，
+// 超出 int64 范围的无符号整数转换成 float64
+func toLoxValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return float64(v.Uint())
+		}
+		return int64(v.Uint())
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return toLoxValue(v.Elem())
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		if v.IsNil() {
+			return nil
+		}
+	}
+	return v.Interface()
+}
+
+// goToLox 转换宿主传入的值，和 toLoxValue 的区别是 Go 函数会被包装成原生函数
+func goToLox(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Func && !v.IsNil() {
+		if native, err := NewNativeFunction("host", value); err == nil {
+			return native
+		}
+	}
+	return toLoxValue(v)
+}
+
+// describeType 返回类型 t 在错误信息中的描述
+func describeType(t reflect.Type) string {
+	switch t {
+	case bigIntType:
+		return "a bigint"
+	case decimalType:
+		return "a decimal"
+	}
+	switch t.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a non-negative integer"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	}
+	return "a " + t.String()
+}
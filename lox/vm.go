@@ -0,0 +1,273 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gookit/slog"
+	"io/ioutil"
+	"strings"
+)
+
+// VM 持有一次会话所需的全部状态：解释器、全局环境、局部变量解析结果和错误状态。
+// 不同的 VM 之间互不影响，可以在同一进程中并存。
+type VM struct {
+	interpreter     *Interpreter
+	options         []Option
+	reporter        ErrorReporter
+	customReporter  bool
+	errors          ErrorList
+	hadRuntimeError bool
+
+	// 是否注册 fs 和 os 模块，以及 os.argv 的内容，见 system_native.go
+	systemAccess bool
+	args         []string
+}
+
+// Option 用于在创建 VM 时调整配置
+type Option func(vm *VM)
+
+// WithErrorReporter 设置错误输出方式，传 nil 表示不输出任何错误
+func WithErrorReporter(reporter ErrorReporter) Option {
+	return func(vm *VM) {
+		vm.reporter = reporter
+		vm.customReporter = true
+	}
+}
+
+func NewVM(options ...Option) *VM {
+	vm := &VM{
+		options: options,
+	}
+	vm.Reset()
+	return vm
+}
+
+// Interpreter 返回 VM 内部使用的解释器
+func (vm *VM) Interpreter() *Interpreter {
+	return vm.interpreter
+}
+
+// DefineNative 把 Go 函数注册成全局 Lox 函数，见 Interpreter.DefineNative
+func (vm *VM) DefineNative(name string, fn interface{}) error {
+	return vm.interpreter.DefineNative(name, fn)
+}
+
+// DefineClass 把原生类注册成全局变量，见 Interpreter.DefineClass
+func (vm *VM) DefineClass(class *NativeClass) {
+	vm.interpreter.DefineClass(class)
+}
+
+// DefineGlobal 定义一个全局变量，见 Interpreter.DefineGlobal
+func (vm *VM) DefineGlobal(name string, value interface{}) {
+	vm.interpreter.DefineGlobal(name, value)
+}
+
+// Run 扫描、解析并执行一段源码。静态错误以 ErrorList 返回，运行时错误以 *RuntimeError 返回。
+// 同一个 VM 多次调用 Run 时共享全局环境，这正是 REPL 需要的行为。
+func (vm *VM) Run(source string) error {
+	return vm.RunContext(context.Background(), source)
+}
+
+// RunContext 和 Run 一样，但 ctx 被取消时脚本会以 RuntimeError 中止，可以用 errors.Is 判断原因
+func (vm *VM) RunContext(ctx context.Context, source string) error {
+	return vm.run(ctx, &Source{Text: source})
+}
+
+// RunFile 读取并执行文件，错误信息中会带上文件名
+func (vm *VM) RunFile(filename string) error {
+	code, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("Error reading file: %s", filename)
+	}
+	return vm.run(context.Background(), &Source{Name: filename, Text: string(code)})
+}
+
+func (vm *VM) run(ctx context.Context, source *Source) error {
+	vm.errors = nil
+	vm.hadRuntimeError = false
+
+	scanner := NewScanner(vm, source)
+	tokens := scanner.scanTokens()
+
+	parser := NewParse(vm, tokens)
+	statements := parser.parse()
+	if vm.hadError() {
+		return vm.errors
+	}
+
+	resolver := NewResolver(vm)
+	resolver.resolveStmt(statements)
+	if vm.hadError() {
+		return vm.errors
+	}
+
+	nested := vm.interpreter.entered > 0
+	defer vm.interpreter.enter(ctx)()
+	if err := vm.interpreter.interpret(statements); err != nil {
+		// 原生函数中再次进入时错误交给外层报告，避免同一个错误报告两次
+		if !nested {
+			vm.reportRuntimeError(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// Reset 丢弃全部全局定义和错误状态，VM 回到刚创建时的样子，创建时传入的 Option 会重新生效
+func (vm *VM) Reset() {
+	vm.interpreter = NewInterpreter()
+	vm.interpreter.loader = newModuleLoader(vm)
+	vm.reporter = nil
+	vm.customReporter = false
+	vm.errors = nil
+	vm.hadRuntimeError = false
+	vm.systemAccess = false
+	vm.args = nil
+	for _, option := range vm.options {
+		option(vm)
+	}
+	if vm.systemAccess {
+		vm.interpreter.defineSystemModules(vm.args)
+	}
+	if !vm.customReporter {
+		if vm.interpreter.stderr != nil {
+			vm.reporter = &WriterReporter{Writer: vm.interpreter.stderr}
+		} else {
+			vm.reporter = &SlogReporter{}
+		}
+	}
+}
+
+// RunPrompt 逐行读取 VM 的输入并执行，直到输入结束或者脚本调用 os.exit，后者返回 *ExitError
+func (vm *VM) RunPrompt() error {
+	reader := vm.interpreter.Stdin()
+	stdout := vm.interpreter.Stdout()
+	for {
+		fmt.Fprint(stdout, "> ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			slog.Errorf("input error:%v", err)
+			return nil
+		}
+		code := strings.TrimRight(input, "\n")
+		var exitError *ExitError
+		if err := vm.Run(code); errors.As(err, &exitError) {
+			return exitError
+		}
+		fmt.Fprint(stdout, "\n")
+	}
+}
+
+func (vm *VM) hadError() bool {
+	return len(vm.errors) > 0
+}
+
+func (vm *VM) scanError(token *Token, message string) {
+	vm.report(NewScanError(token, message))
+}
+
+func (vm *VM) parseError(token *Token, message string) *ParseError {
+	err := NewParseError(token, message)
+	vm.report(err)
+	return err
+}
+
+func (vm *VM) resolveError(token *Token, message string) {
+	vm.report(NewResolveError(token, message))
+}
+
+func (vm *VM) report(err error) {
+	vm.errors = append(vm.errors, err)
+	if vm.reporter != nil {
+		vm.reporter.ReportError(err)
+	}
+}
+
+func (vm *VM) reportRuntimeError(err *RuntimeError) {
+	vm.hadRuntimeError = true
+	// os.exit 是正常的退出，不是错误
+	var exitError *ExitError
+	if errors.As(err, &exitError) {
+		return
+	}
+	if vm.reporter != nil {
+		vm.reporter.ReportError(err)
+	}
+}
+
+// GetGlobal 读取全局变量，通常用来取出脚本中定义的函数或类再交给 Call
+func (vm *VM) GetGlobal(name string) (interface{}, error) {
+	value, ok := vm.interpreter.GetGlobal(name)
+	if !ok {
+		return nil, fmt.Errorf("Undefined variable '%s'.", name)
+	}
+	return value, nil
+}
+
+// Call 从 Go 调用 Lox 的函数、类或原生函数。参数中的 Go 值会先转换成 Lox 值，
+// 脚本中的运行时错误以 *RuntimeError 返回而不会 panic。
+func (vm *VM) Call(fn interface{}, args ...interface{}) (interface{}, error) {
+	return vm.CallContext(context.Background(), fn, args...)
+}
+
+// CallContext 和 Call 一样，但 ctx 被取消时调用会以 RuntimeError 中止。
+// 在原生函数中回调 Lox 时沿用外层 Run 或 Call 的预算和 context，ctx 不起作用
+func (vm *VM) CallContext(ctx context.Context, fn interface{}, args ...interface{}) (interface{}, error) {
+	callable, ok := fn.(LoxCallable)
+	if !ok {
+		return nil, fmt.Errorf("Can only call functions and classes, got %T.", fn)
+	}
+	paren := NewToken(TokenType_IDENTIFIER, callable.String(), nil, 0)
+
+	arguments := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		arguments = append(arguments, goToLox(arg))
+	}
+
+	nested := vm.interpreter.entered > 0
+	defer vm.interpreter.enter(ctx)()
+	return vm.protect(nested, func() interface{} {
+		return vm.interpreter.call(callable, paren, arguments)
+	})
+}
+
+// CallMethod 按名字取出 object 的方法（绑定好 this）并调用
+func (vm *VM) CallMethod(object interface{}, name string, args ...interface{}) (interface{}, error) {
+	token := NewToken(TokenType_IDENTIFIER, name, nil, 0)
+	method, err := vm.protect(vm.interpreter.entered > 0, func() interface{} {
+		switch instance := object.(type) {
+		case *LoxInstance:
+			return instance.Get(token)
+		case *NativeInstance:
+			return instance.Get(token)
+		}
+		panic(NewRuntimeError(token, "Only instances have properties."))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vm.Call(method, args...)
+}
+
+// protect 执行 fn，把脚本中抛出的 RuntimeError 转成返回的 error。
+// nested 表示在原生函数中回调，这时错误会经过原生函数传回外层，只由最外层报告
+func (vm *VM) protect(nested bool, fn func() interface{}) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch v := r.(type) {
+			case *RuntimeError:
+				if !nested {
+					vm.reportRuntimeError(v)
+				}
+				result, err = nil, v
+			case *Return:
+				result, err = v.value, nil
+			default:
+				panic(r)
+			}
+		}
+	}()
+
+	return fn(), nil
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"fmt"
+	"errors"
 	"github.com/gookit/slog"
 	"lox_go/lox"
 	"os"
@@ -11,13 +11,30 @@ func main() {
 
 	slog.SetLogLevel(slog.InfoLevel)
 
+	// 命令行运行的脚本可以使用 fs 和 os 模块，脚本后面的参数都交给脚本，通过 os.argv 读取
 	args := os.Args
-	if len(args) > 2 {
-		fmt.Printf("Usage: %s [script]\n", args[0])
-		os.Exit(64)
-	} else if len(args) == 2 {
-		lox.RunFile(args[1])
-	} else {
-		lox.RunPrompt()
+	if len(args) >= 2 {
+		if err := lox.RunFile(args[1], lox.WithSystemAccess(true), lox.WithArgs(args[2:]...)); err != nil {
+			os.Exit(exitCode(err))
+		}
+	} else if err := lox.RunPrompt(lox.WithSystemAccess(true)); err != nil {
+		os.Exit(exitCode(err))
 	}
 }
+
+func exitCode(err error) int {
+	var exitError *lox.ExitError
+	if errors.As(err, &exitError) {
+		return exitError.Code
+	}
+	var runtimeError *lox.RuntimeError
+	if errors.As(err, &runtimeError) {
+		return 70
+	}
+	var errorList lox.ErrorList
+	if errors.As(err, &errorList) {
+		return 65
+	}
+	slog.Errorf(err.Error())
+	return 66
+}
@@ -0,0 +1,156 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"lox_go/lox"
+	"testing"
+	"time"
+)
+
+const codeInfiniteLoop = `
+var i = 0;
+while (true) {
+  i = i + 1;
+}
+`
+
+func TestBudgetMaxSteps(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil), lox.WithMaxSteps(1000))
+
+	var runtimeError *lox.RuntimeError
+	err := vm.Run(codeInfiniteLoop)
+	if !errors.Is(err, lox.ErrBudgetExceeded) || !errors.As(err, &runtimeError) {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if line := runtimeError.Token.Line(); line != 3 && line != 4 {
+		t.Fatalf("unexpected line %d", line)
+	}
+
+	// 预算在每次 Run 时重置
+	if err := vm.Run(`var a = 1;`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBudgetTimeout(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil), lox.WithTimeout(20*time.Millisecond))
+	if err := vm.Run(codeInfiniteLoop); !errors.Is(err, lox.ErrBudgetExceeded) {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+}
+
+func TestBudgetContext(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := vm.RunContext(ctx, codeInfiniteLoop); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+const codeUnboundedRecursion = `
+fun fib(n) {
+  return fib(n - 1) + fib(n - 2);
+}
+fib(10);
+`
+
+func TestStackOverflow(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+
+	var runtimeError *lox.RuntimeError
+	err := vm.Run(codeUnboundedRecursion)
+	if !errors.As(err, &runtimeError) || runtimeError.Message != "Stack overflow." {
+		t.Fatalf("expected stack overflow, got %v", err)
+	}
+	if runtimeError.Token.Line() != 3 {
+		t.Fatalf("unexpected line %d", runtimeError.Token.Line())
+	}
+
+	vm = lox.NewVM(lox.WithErrorReporter(nil), lox.WithMaxCallDepth(10))
+	if err := vm.Run(`
+fun depth(n) { if (n > 0) depth(n - 1); }
+depth(9);
+`); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.Run(`depth(10);`); !errors.As(err, &runtimeError) || runtimeError.Message != "Stack overflow." {
+		t.Fatalf("expected stack overflow, got %v", err)
+	}
+}
+
+const codeCallbackLoop = `
+var i = 0;
+while (i < 100000) {
+  i = i + 1;
+  callback(fun() { return i; });
+}
+`
+
+func TestBudgetAcrossCallbacks(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil), lox.WithMaxSteps(1000))
+	// 原生函数回调 Lox 时不能重置外层的预算
+	vm.DefineNative("callback", func(fn interface{}) (interface{}, error) {
+		return vm.Call(fn)
+	})
+	if err := vm.Run(codeCallbackLoop); !errors.Is(err, lox.ErrBudgetExceeded) {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+	if err := vm.Run(`fun spin() { while (true) {} }`); err != nil {
+		t.Fatal(err)
+	}
+	spin, _ := vm.GetGlobal("spin")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if _, err := vm.CallContext(ctx, spin); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+const codeCatchCallbackBudget = `
+var i = 0;
+while (true) {
+  try {
+    callback(fun() { while (true) { i = i + 1; } });
+  } catch (e) {
+    print "caught";
+  }
+}
+`
+
+type countingReporter struct {
+	errors []error
+}
+
+func (r *countingReporter) ReportError(err error) {
+	r.errors = append(r.errors, err)
+}
+
+func TestBudgetErrorFromCallback(t *testing.T) {
+	var stdout bytes.Buffer
+	reporter := &countingReporter{}
+	vm := lox.NewVM(lox.WithErrorReporter(reporter), lox.WithStdout(&stdout), lox.WithMaxSteps(1000))
+	vm.DefineNative("callback", func(fn interface{}) (interface{}, error) {
+		return vm.Call(fn)
+	})
+
+	// 回调中超出预算的错误不能被脚本捕获，原因和信息都要保留，而且只报告一次
+	var runtimeError *lox.RuntimeError
+	err := vm.Run(codeCatchCallbackBudget)
+	if !errors.Is(err, lox.ErrBudgetExceeded) || !errors.As(err, &runtimeError) {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if runtimeError.Message != "Execution step limit exceeded." || runtimeError.Token.Line() != 5 {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if stdout.String() != "" || len(reporter.errors) != 1 {
+		t.Fatalf("unexpected output %q, reported %v", stdout.String(), reporter.errors)
+	}
+}
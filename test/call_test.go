@@ -0,0 +1,107 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+func TestCallFromGo(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+	err := vm.Run(`
+fun add(a, b) { return a + b; }
+fun apply(f, x) { return f(x); }
+fun fail() { return 1 - "a"; }
+class Counter {
+  init(start) { this.count = start; }
+  inc(n) { this.count = this.count + n; return this.count; }
+}
+var counter = Counter(10);
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	add, err := vm.GetGlobal("add")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result, err := vm.Call(add, 1, 2.5); err != nil || result != 3.5 {
+		t.Fatalf("add: got %v, %v", result, err)
+	}
+	if result, err := vm.Call(add, "a", "b"); err != nil || result != "ab" {
+		t.Fatalf("add: got %v, %v", result, err)
+	}
+
+	apply, _ := vm.GetGlobal("apply")
+	double := func(x float64) float64 { return x * 2 }
+	if result, err := vm.Call(apply, double, 21); err != nil || result != 42.0 {
+		t.Fatalf("apply: got %v, %v", result, err)
+	}
+
+	var runtimeError *lox.RuntimeError
+	fail, _ := vm.GetGlobal("fail")
+	if _, err := vm.Call(fail); !errors.As(err, &runtimeError) {
+		t.Fatalf("fail: expected runtime error, got %v", err)
+	}
+	if _, err := vm.Call(add, 1); !errors.As(err, &runtimeError) || runtimeError.Message != "Expected 2 arguments but got 1." {
+		t.Fatalf("arity: got %v", err)
+	}
+
+	counter, _ := vm.GetGlobal("counter")
+	if result, err := vm.CallMethod(counter, "inc", 5); err != nil || result != int64(15) {
+		t.Fatalf("inc: got %v, %v", result, err)
+	}
+	if _, err := vm.CallMethod(counter, "missing"); !errors.As(err, &runtimeError) {
+		t.Fatalf("missing: expected runtime error, got %v", err)
+	}
+
+	if _, err := vm.GetGlobal("nope"); err == nil {
+		t.Fatal("expected error for undefined global")
+	}
+}
+
+const codeReentrantCall = `
+fun twice(x) { return x * 2; }
+fun sum(n) {
+  return each(fun(x) { return twice(x); }, n);
+}
+print sum(4);
+fun broken() {
+  return each(fun(x) { return x - "a"; }, 1);
+}
+broken();
+`
+
+func TestCallFromNativeCallback(t *testing.T) {
+	var stdout bytes.Buffer
+	vm := lox.NewVM(lox.WithErrorReporter(nil), lox.WithStdout(&stdout))
+	// each 在原生函数中回调 Lox 函数，累加 fn(0) ... fn(n-1)
+	vm.DefineNative("each", func(fn interface{}, n int) (interface{}, error) {
+		total := int64(0)
+		for k := 0; k < n; k++ {
+			result, err := vm.Call(fn, k)
+			if err != nil {
+				return nil, err
+			}
+			total += result.(int64)
+		}
+		return total, nil
+	})
+
+	var runtimeError *lox.RuntimeError
+	err := vm.Run(codeReentrantCall)
+	if !errors.As(err, &runtimeError) || runtimeError.Token.Line() != 8 {
+		t.Fatalf("expected runtime error at line 8, got %v", err)
+	}
+	if stdout.String() != "12" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+
+	// 回调出错之后 VM 仍然可用
+	stdout.Reset()
+	if err := vm.Run(`print sum(3);`); err != nil || stdout.String() != "6" {
+		t.Fatalf("unexpected result %v %q", err, stdout.String())
+	}
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	"errors"
+	"lox_go/lox"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiagnosticSnippet(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "broken.lox")
+	if err := os.WriteFile(filename, []byte("var a = 1;\n\tprint a\nvar b = 2;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+	var errorList lox.ErrorList
+	if err := vm.RunFile(filename); !errors.As(err, &errorList) {
+		t.Fatalf("expected static errors, got %v", err)
+	}
+	parseError, ok := errorList[0].(*lox.ParseError)
+	if !ok {
+		t.Fatalf("expected *lox.ParseError, got %T", errorList[0])
+	}
+	if parseError.File != filename || parseError.Line != 3 || parseError.Column != 1 || parseError.Offset != 20 || parseError.Length != 3 {
+		t.Fatalf("unexpected position %s:%d:%d offset %d length %d",
+			parseError.File, parseError.Line, parseError.Column, parseError.Offset, parseError.Length)
+	}
+	want := " --> " + filename + ":3:1\n" +
+		"3 | var b = 2;\n" +
+		"  | ^^^\n"
+	if snippet := parseError.Snippet(); snippet != want {
+		t.Fatalf("unexpected snippet:\n%s", snippet)
+	}
+}
+
+func TestDiagnosticSnippetKeepsTabs(t *testing.T) {
+	var runtimeError *lox.RuntimeError
+	err := lox.Eval("var s = \"x\";\n\tprint s - 1;", lox.WithErrorReporter(nil))
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("expected runtime error, got %v", err)
+	}
+	want := " --> 2:10\n" +
+		"2 | \tprint s - 1;\n" +
+		"  | \t        ^\n"
+	if snippet := runtimeError.Snippet(); snippet != want {
+		t.Fatalf("unexpected snippet:\n%q", snippet)
+	}
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"strings"
+	"testing"
+)
+
+const codeTryCatch = `
+try {
+  print "a";
+  throw "boom";
+  print "not reached";
+} catch (e) {
+  print e;
+} finally {
+  print "|finally";
+}
+print "|";
+
+fun divide(a, b) {
+  if (b == 0) throw Error("division by zero");
+  return a ~/ b;
+}
+try {
+  divide(1, 0);
+} catch (e) {
+  print e.message + "@" + e.line;
+}
+print "|";
+
+try {
+  var x = nil;
+  x.field;
+} catch (e) {
+  print e.message + "@" + e.line;
+}
+print "|";
+
+class NotFound < Error {
+  init(key) { super.init("missing " + key); this.key = key; }
+}
+try {
+  throw NotFound("k");
+} catch (e) {
+  print e.key + ":" + e.message;
+}
+print "|";
+
+fun early() {
+  try {
+    return "try";
+  } finally {
+    print "cleanup ";
+  }
+}
+print early();
+print "|";
+
+for (var i = 0; i < 3; i = i + 1) {
+  try {
+    if (i == 1) continue;
+    print i;
+  } finally {
+    print ".";
+  }
+}
+print "|";
+
+try {
+  try {
+    throw nil;
+  } finally {
+    print "inner ";
+  }
+} catch (e) {
+  print e == nil;
+}
+`
+
+func TestTryCatch(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(codeTryCatch, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	want := `aboom|finally|division by zero@14|Only instances have properties.@26|k:missing k|cleanup try|0..2.|inner true`
+	if stdout.String() != want {
+		t.Fatalf("unexpected output\n got: %s\nwant: %s", stdout.String(), want)
+	}
+}
+
+func TestCaughtErrorStack(t *testing.T) {
+	var stdout bytes.Buffer
+	code := `
+fun inner() { return 1 + nil; }
+fun outer() { return inner(); }
+try { outer(); } catch (e) { print e.stack; }
+`
+	if err := lox.Eval(code, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	want := "[line 2] in inner()\n[line 3] in outer()\n[line 4] in script\n"
+	if stdout.String() != want {
+		t.Fatalf("unexpected stack %q", stdout.String())
+	}
+}
+
+func TestUncaughtThrow(t *testing.T) {
+	var stderr bytes.Buffer
+	err := lox.Eval("fun f() {\n  throw Error(\"bad \" + 1);\n}\nf();", lox.WithStderr(&stderr))
+	var runtimeError *lox.RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("expected runtime error, got %v", err)
+	}
+	if runtimeError.Error() != "[line 2]bad 1" {
+		t.Fatalf("unexpected error %q", runtimeError.Error())
+	}
+	if !strings.Contains(stderr.String(), "[line 2] in f()\n[line 4] in script") {
+		t.Fatalf("expected stack trace in report, got %q", stderr.String())
+	}
+	if _, ok := runtimeError.Value.(*lox.LoxInstance); !ok {
+		t.Fatalf("expected thrown value on error, got %#v", runtimeError.Value)
+	}
+}
+
+func TestBudgetErrorsAreNotCatchable(t *testing.T) {
+	var stdout bytes.Buffer
+	err := lox.Eval(`try { while (true) {} } catch (e) { print "caught"; } finally { print "finally"; }`,
+		lox.WithMaxSteps(1000), lox.WithStdout(&stdout), lox.WithErrorReporter(nil))
+	if !errors.Is(err, lox.ErrBudgetExceeded) {
+		t.Fatalf("expected budget error, got %v", err)
+	}
+	if strings.Contains(stdout.String(), "caught") {
+		t.Fatalf("budget error must not be caught, output %q", stdout.String())
+	}
+}
+
+func TestTryParseErrors(t *testing.T) {
+	var errorList lox.ErrorList
+	err := lox.Eval(`try { print 1; } print 2;`, lox.WithErrorReporter(nil))
+	if !errors.As(err, &errorList) || errorList[0].(*lox.ParseError).Message != "Expect 'catch' or 'finally' after try block." {
+		t.Fatalf("expected parse error, got %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package test
+
+import (
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+// runtimeErrorCase 是一段会出运行时错误的代码和预期的错误信息，line 为 0 时不检查行号
+type runtimeErrorCase struct {
+	code    string
+	message string
+	line    int
+}
+
+// expectRuntimeErrors 逐个执行 cases，检查每段代码都以预期的 RuntimeError 结束
+func expectRuntimeErrors(t *testing.T, cases []runtimeErrorCase, options ...lox.Option) {
+	t.Helper()
+	options = append([]lox.Option{lox.WithErrorReporter(nil)}, options...)
+	for _, c := range cases {
+		var runtimeError *lox.RuntimeError
+		err := lox.Eval(c.code, options...)
+		if !errors.As(err, &runtimeError) || runtimeError.Message != c.message {
+			t.Errorf("%s: expected %q, got %v", c.code, c.message, err)
+		} else if c.line != 0 && runtimeError.Token.Line() != c.line {
+			t.Errorf("%s: expected %q on line %d, got line %d", c.code, c.message, c.line, runtimeError.Token.Line())
+		}
+	}
+}
+
+// staticErrorCase 是一段有静态错误的代码和第一个错误预期的信息，line 和 column 为 0 时不检查位置
+type staticErrorCase struct {
+	code    string
+	message string
+	line    int
+	column  int
+}
+
+// expectStaticErrors 逐个执行 cases，检查每段代码的第一个静态错误
+func expectStaticErrors(t *testing.T, cases []staticErrorCase) {
+	t.Helper()
+	for _, c := range cases {
+		var errorList lox.ErrorList
+		if err := lox.Eval(c.code, lox.WithErrorReporter(nil)); !errors.As(err, &errorList) {
+			t.Errorf("%s: expected static errors, got %v", c.code, err)
+			continue
+		}
+		message, line, column := staticErrorFields(errorList[0])
+		if message != c.message || (c.line != 0 && line != c.line) || (c.column != 0 && column != c.column) {
+			t.Errorf("%s: expected %q at %d:%d, got %v at %d:%d", c.code, c.message, c.line, c.column, errorList[0], line, column)
+		}
+	}
+}
+
+func staticErrorFields(err error) (string, int, int) {
+	switch e := err.(type) {
+	case *lox.ScanError:
+		return e.Message, e.Line, e.Column
+	case *lox.ParseError:
+		return e.Message, e.Line, e.Column
+	case *lox.ResolveError:
+		return e.Message, e.Line, e.Column
+	}
+	return err.Error(), 0, 0
+}
@@ -0,0 +1,64 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+const codeLambda = `
+fun makeAdder(n) {
+  return (x) => x + n;
+}
+var add2 = makeAdder(2);
+print add2(40); print " ";
+
+var counter = fun () {
+  var count = 0;
+  return fun () { count = count + 1; return count; };
+}();
+counter();
+print counter(); print " ";
+
+var xs = [3, 1, 2];
+xs.sort((a, b) => b - a);
+print xs; print " ";
+
+var apply = (f, a, b) => f(a, b);
+print apply((a, b) => { var s = a * b; return s + 1; }, 6, 7); print " ";
+print (() => "no args")(); print " ";
+print (1 + 2) * 3; print " ";
+print fun () {};
+`
+
+func TestFunctionExpressions(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(codeLambda, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	want := "42 2 [3, 2, 1] 43 no args 9 <fn anonymous>"
+	if stdout.String() != want {
+		t.Fatalf("unexpected output\n got: %s\nwant: %s", stdout.String(), want)
+	}
+}
+
+func TestAnonymousFunctionStackTrace(t *testing.T) {
+	var runtimeError *lox.RuntimeError
+	err := lox.Eval("var f = (x) => x + nil;\nf(1);", lox.WithErrorReporter(nil))
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("expected runtime error, got %v", err)
+	}
+	want := "[line 1] in anonymous()\n[line 2] in script\n"
+	if runtimeError.StackTrace() != want {
+		t.Fatalf("unexpected stack trace %q", runtimeError.StackTrace())
+	}
+}
+
+func TestFunctionExpressionErrors(t *testing.T) {
+	expectStaticErrors(t, []staticErrorCase{
+		{`var f = fun x() {};`, "Expect '(' after 'fun'.", 1, 13},
+		{`var f = (a, 1) => a;`, "Expect ')' after expression.", 1, 11},
+		{`var f = fun (a) a;`, "Expect '{' before function body.", 1, 17},
+	})
+}
@@ -0,0 +1,103 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+const codeList = `
+var xs = [3, 1, 2,];
+xs.push(5);
+print xs; print " ";
+print xs.len(); print " ";
+print xs[0] + xs[3]; print " ";
+xs[1] = "one";
+print xs; print " ";
+print xs.pop(); print " ";
+xs.insert(0, nil);
+print xs; print " ";
+print xs.slice(1, 3); print " ";
+print xs.slice(2); print " ";
+var nums = [10, 2.5, 7n, -1];
+nums.sort();
+print nums; print " ";
+var words = ["pear", "apple", "fig"];
+words.sort(byLength);
+print words; print " ";
+var nested = [[1, 2], []];
+nested[1].push(nested[0][1]);
+print nested;
+`
+
+func TestList(t *testing.T) {
+	var stdout bytes.Buffer
+	vm := lox.NewVM(lox.WithStdout(&stdout))
+	vm.DefineNative("len", func(s string) int { return len(s) })
+	if err := vm.Run(`fun byLength(a, b) { return len(a) - len(b); }` + codeList); err != nil {
+		t.Fatal(err)
+	}
+	want := `[3, 1, 2, 5] 4 8 [3, "one", 2, 5] 5 [nil, 3, "one", 2] [3, "one"] ["one", 2] [-1, 2.5, 7, 10] ["fig", "pear", "apple"] [[1, 2], [2]]`
+	if stdout.String() != want {
+		t.Fatalf("unexpected output\n got: %s\nwant: %s", stdout.String(), want)
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	cases := []runtimeErrorCase{
+		{"var xs = [1, 2];\nprint xs[2];", "List index 2 is out of range for length 2.", 2},
+		{"var xs = [1, 2];\nxs[-1] = 0;", "List index -1 is out of range for length 2.", 2},
+		{`print [1][0.5];`, "List index must be an integer.", 1},
+		{`print [].pop();`, "Can't pop from an empty list.", 1},
+		{`[1].insert(2, 0);`, "List index 2 is out of range for length 1.", 1},
+		{`[1, 2].slice(2, 1);`, "Slice start 2 is after end 1.", 1},
+		{`[1, "a"].sort();`, "Can only sort lists of numbers or strings without a comparator.", 1},
+		{`[1].slice();`, "Expected 1 to 2 arguments but got 0.", 1},
+		{`[1].missing();`, "Undefined property 'missing'.", 1},
+		{`var n = 123; print n[0];`, "Only lists, maps and strings can be indexed.", 1},
+	}
+	expectRuntimeErrors(t, cases)
+}
+
+func TestSelfReferencingList(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(`var xs = [1]; xs.push(xs); print xs;`, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "[1, [...]]" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestSortComparatorModifiesList(t *testing.T) {
+	var stdout bytes.Buffer
+	err := lox.Eval(`
+var xs = [5, 3, 4, 1, 2];
+xs.sort(fun(a, b) { if (xs.len() > 0) xs.pop(); return a - b; });
+print xs;
+`, lox.WithStdout(&stdout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "[1, 2, 3, 4, 5]" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestCallBuiltinMethodFromGo(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+	if err := vm.Run(`var xs = [1]; var push = xs.push; var slice = xs.slice;`); err != nil {
+		t.Fatal(err)
+	}
+	push, _ := vm.GetGlobal("push")
+	slice, _ := vm.GetGlobal("slice")
+
+	var runtimeError *lox.RuntimeError
+	if _, err := vm.Call(push); !errors.As(err, &runtimeError) || err.Error() != "[line 0]Expected 1 arguments but got 0." {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if _, err := vm.Call(slice, 5); !errors.As(err, &runtimeError) || runtimeError.Message != "List index 5 is out of range for length 1." {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
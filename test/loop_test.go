@@ -0,0 +1,85 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+const codeBreakContinue = `
+for (var i = 0; i < 10; i = i + 1) {
+  if (i == 2) continue;
+  if (i == 5) break;
+  print i;
+}
+print "|";
+var n = 0;
+while (true) {
+  n = n + 1;
+  if (n % 2 == 0) continue;
+  if (n > 7) break;
+  print n;
+}
+print "|";
+outer: for (var a = 0; a < 3; a = a + 1) {
+  inner: for (var b = 0; b < 3; b = b + 1) {
+    if (b == 1) continue outer;
+    if (a == 2) break outer;
+    print a + "" + b;
+  }
+}
+print "|";
+var k = 0;
+loop: while (k < 3) {
+  k = k + 1;
+  for (;;) { continue loop; }
+}
+print k;
+`
+
+func TestBreakContinue(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(codeBreakContinue, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "0134|1357|0010|3" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestBreakInsideFunctionInLoop(t *testing.T) {
+	var stdout bytes.Buffer
+	code := `
+fun find(xs, target) {
+  var found = -1;
+  for (var i = 0; i < xs.len(); i = i + 1) {
+    if (xs[i] == target) { found = i; break; }
+  }
+  return found;
+}
+for (var j = 0; j < 2; j = j + 1) { print find([4, 5, 6], 5 + j); }
+`
+	if err := lox.Eval(code, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "12" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestLoopControlErrors(t *testing.T) {
+	expectStaticErrors(t, []staticErrorCase{
+		{`break;`, "Can't use 'break' outside of a loop.", 1, 1},
+		{`if (true) continue;`, "Can't use 'continue' outside of a loop.", 1, 11},
+		{`while (true) { fun f() { break; } }`, "Can't use 'break' outside of a loop.", 1, 26},
+		{`while (true) { break nope; }`, "Undefined loop label 'nope'.", 1, 22},
+		{`a: while (true) { a: while (true) {} }`, "Label 'a' is already used by an enclosing loop.", 1, 19},
+	})
+
+	var errorList lox.ErrorList
+	err := lox.Eval(`a: print 1;`, lox.WithErrorReporter(nil))
+	if !errors.As(err, &errorList) || errorList[0].(*lox.ParseError).Message != "Expect loop after label." {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
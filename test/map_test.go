@@ -0,0 +1,75 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+const codeMap = `
+class Point { init(x) { this.x = x; } }
+var p = Point(1);
+var m = {"b": 1, "a": [1, 2], 3: true,};
+m["c"] = nil;
+m[p] = "point";
+m[false] = 0;
+print m; print " ";
+print m.len(); print " ";
+print m[3.0] and m[3n] and m[3.00m]; print " ";
+m[3.0] = "three";
+print m.keys(); print " ";
+print m.values(); print " ";
+print m.has(p) and !m.has(Point(1)) and m.has("c") and !m.has("z"); print " ";
+print m.remove("b"); print " ";
+print m.remove("b"); print " ";
+print m.keys(); print " ";
+print m[p]; print " ";
+var counts = {};
+var words = ["a", "b", "a"];
+for (var i = 0; i < words.len(); i = i + 1) {
+  var w = words[i];
+  if (counts.has(w)) counts[w] = counts[w] + 1; else counts[w] = 1;
+}
+print counts; print " ";
+print {0.5: "half"}[1 / 2] + {0.5m: "!"}[0.5];
+`
+
+func TestMap(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(codeMap, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"b": 1, "a": [1, 2], 3: true, "c": nil, Point instance: "point", false: 0} 6 true ` +
+		`["b", "a", 3, "c", Point instance, false] [1, [1, 2], "three", nil, "point", 0] true 1  ` +
+		`["a", 3, "c", Point instance, false] point {"a": 2, "b": 1} half!`
+	if stdout.String() != want {
+		t.Fatalf("unexpected output\n got: %s\nwant: %s", stdout.String(), want)
+	}
+}
+
+func TestMapErrors(t *testing.T) {
+	cases := []runtimeErrorCase{
+		{`var m = {"a": 1}; print m["b"];`, `Undefined key "b".`, 1},
+		{`var m = {}; m[0 / 0] = 1;`, "Map key can't be NaN.", 1},
+		{`print {}.missing;`, "Undefined property 'missing'.", 1},
+		{`print {}.has();`, "Expected 1 arguments but got 0.", 1},
+	}
+	expectRuntimeErrors(t, cases)
+
+	var errorList lox.ErrorList
+	err := lox.Eval(`var m = {"a" 1};`, lox.WithErrorReporter(nil))
+	if !errors.As(err, &errorList) || errorList[0].(*lox.ParseError).Message != "Expect ':' after map key." {
+		t.Errorf("expected parse error, got %v", err)
+	}
+}
+
+func TestBlockStillParsesAsBlock(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(`{ var a = {}; print a.len(); }`, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "0" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
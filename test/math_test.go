@@ -0,0 +1,76 @@
+package test
+
+import (
+	"bytes"
+	"lox_go/lox"
+	"testing"
+)
+
+const codeMath = `
+print math.sqrt(16);
+print " ";
+print math.pow(2, 10);
+print " ";
+print math.pow(2, 0.5) == math.sqrt(2);
+print " ";
+print math.floor(2.7) + math.ceil(2.1) + math.round(-2.5);
+print " ";
+print math.round(2.675m);
+print " ";
+print math.abs(-3) + math.abs(-1.5);
+print " ";
+print math.min(3, 1.5, 2) + math.max(1, 4, 2);
+print " ";
+print math.isNan(math.nan) and !math.isNan(1);
+print " ";
+print math.inf > 1000000;
+print " ";
+print math.floor(math.pi) + math.round(math.e);
+print " ";
+print math.sin(0) + math.cos(0) + math.log(1) + math.log10(100) + math.log2(8);
+print " ";
+print math.pow(1.5m, 2);
+`
+
+func TestMathModule(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(codeMath, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	want := "4 1024 true 2 3 4.5 5.5 true true 6 6 2.25"
+	if stdout.String() != want {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestImportMath(t *testing.T) {
+	var stdout bytes.Buffer
+	err := lox.Eval(`
+import "math" as m;
+import { sqrt, pi as PI } from "math";
+print m.sqrt(9) + sqrt(4) + math.floor(PI);
+`, lox.WithStdout(&stdout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "8" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestMathErrors(t *testing.T) {
+	cases := []runtimeErrorCase{
+		{`math.pow(3, 200000000);`, "Result of pow is too large.", 1},
+		{`math.pow(3n, 200000000);`, "Result of pow is too large.", 1},
+		{`math.pow(1.5m, 200000000);`, "Result of pow is too large.", 1},
+		{`math.pow(0.1m, 200000000);`, "Result of pow is too large.", 1},
+		{`math.sqrt("x");`, "Argument 1 of 'sqrt' must be a number.", 1},
+		{`math.floor("x");`, "Argument of 'floor' must be a number, got \"x\".", 1},
+		{`math.max(1, nil);`, "Argument of 'max' must be a number, got nil.", 1},
+		{`math.abs([1]);`, "Argument of 'abs' must be a number, got [1].", 1},
+		{`math.floor({"a": 1});`, "Argument of 'floor' must be a number, got {\"a\": 1}.", 1},
+		{`math.min();`, "Expected at least 1 arguments but got 0.", 1},
+		{`math.cbrt(8);`, "Module 'math' has no export 'cbrt'.", 1},
+	}
+	expectRuntimeErrors(t, cases)
+}
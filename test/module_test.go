@@ -0,0 +1,192 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeModules(t *testing.T, dir string, files map[string]string) {
+	for name, code := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestImportModule(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"lib/math.lox": `
+print "loading math";
+var hidden = 10;
+export fun square(x) { return x * x; }
+export var offset = hidden + 1;
+export class Point { init(x) { this.x = x; } }
+`,
+		"main.lox": `
+import "lib/math.lox" as m;
+import "lib/math" as again;
+import { square, offset as off } from "lib/math.lox";
+print m.square(3);
+print square(4) + off;
+print m.Point(5).x;
+print m;
+print m == again;
+`,
+	})
+
+	var stdout bytes.Buffer
+	vm := lox.NewVM(lox.WithStdout(&stdout), lox.WithSystemAccess(true))
+	if err := vm.RunFile(filepath.Join(dir, "main.lox")); err != nil {
+		t.Fatal(err)
+	}
+	want := "loading math9275<module math>true"
+	if stdout.String() != want {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"a.lox":       `import "b.lox" as b; export var x = 1;`,
+		"b.lox":       `import "a.lox" as a; export var y = 2;`,
+		"lib.lox":     `var secret = 1; export fun open() { return secret; }`,
+		"broken.lox":  `var = 1;`,
+		"missing.lox": `export { nothing };`,
+	})
+
+	expectRuntimeErrors(t, []runtimeErrorCase{
+		{`import "a.lox" as a;`, "Import cycle detected: a.lox -> b.lox -> a.lox.", 1},
+		{`import "lib.lox" as lib; print lib.secret;`, "Module 'lib' has no export 'secret'.", 1},
+		{`import { secret } from "lib.lox";`, "Module 'lib' has no export 'secret'.", 1},
+		{`import "nowhere.lox" as n;`, "Can't find module 'nowhere.lox'.", 1},
+		{`import "broken.lox" as b;`, "Module 'broken.lox' has errors.", 1},
+		{`import "missing.lox" as m;`, "Exported name 'nothing' is not defined.", 1},
+	}, lox.WithModulePath(dir))
+}
+
+func TestModuleSearchPath(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"path/greet.lox": `export fun greet(name) { return "hi " + name; }`,
+		"env/shout.lox":  `export fun shout(s) { return s + "!"; }`,
+	})
+	t.Setenv("LOX_PATH", filepath.Join(dir, "env"))
+
+	var stdout bytes.Buffer
+	err := lox.Eval(`
+import "greet" as g;
+import { shout } from "shout";
+print shout(g.greet("lox"));
+`, lox.WithStdout(&stdout), lox.WithSystemAccess(true), lox.WithModulePath(filepath.Join(dir, "path")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "hi lox!" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestExportOnlyAtTopLevel(t *testing.T) {
+	var errorList lox.ErrorList
+	err := lox.Eval(`fun f() { export var a = 1; }`, lox.WithErrorReporter(nil))
+	if !errors.As(err, &errorList) || !strings.Contains(errorList.Error(), "Can only export from the top level.") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestModuleFunctionsUseModuleGlobals(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"counter.lox": `
+var count = 0;
+var secret = "module";
+export fun inc() { count = count + 1; return count; }
+export fun fact(n) { if (n <= 1) return 1; return n * fact(n - 1); }
+export fun reveal() { return secret; }
+export class Box { get() { return secret; } }
+`,
+		"main.lox": `
+import "counter.lox" as c;
+import { inc, fact } from "counter.lox";
+var count = 100;
+var secret = "main";
+c.inc();
+print inc();
+print " " + fact(5) + " " + c.reveal() + " " + c.Box().get() + " " + count + " " + secret;
+`,
+	})
+
+	var stdout bytes.Buffer
+	vm := lox.NewVM(lox.WithStdout(&stdout), lox.WithSystemAccess(true))
+	if err := vm.RunFile(filepath.Join(dir, "main.lox")); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "2 120 module module 100 main" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestImportWithoutSystemAccess(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"root/lib.lox": `export var name = "lib";`,
+		"outside.lox":  `print "escaped"; export var name = "outside";`,
+	})
+	t.Setenv("LOX_PATH", dir)
+
+	// 默认只能导入 DefineModule 注册的模块
+	denied := []string{
+		`import "` + filepath.Join(dir, "outside.lox") + `" as o;`,
+		`import "outside.lox" as o;`,
+	}
+	for _, code := range denied {
+		var runtimeError *lox.RuntimeError
+		err := lox.Eval(code, lox.WithErrorReporter(nil))
+		if !errors.As(err, &runtimeError) || !strings.HasPrefix(runtimeError.Message, "Can't find module") {
+			t.Errorf("%s: unexpected error %v", code, err)
+		}
+	}
+
+	// WithModulePath 给出的目录可以导入，但不能跳出这些目录
+	root := filepath.Join(dir, "root")
+	var stdout bytes.Buffer
+	vm := lox.NewVM(lox.WithStdout(&stdout), lox.WithErrorReporter(nil), lox.WithModulePath(root))
+	if err := vm.Run(`import "lib" as l; import "math" as m; print l.name;`); err != nil || stdout.String() != "lib" {
+		t.Fatalf("unexpected result %v %q", err, stdout.String())
+	}
+	var runtimeError *lox.RuntimeError
+	if err := vm.Run(`import "../outside.lox" as o;`); !errors.As(err, &runtimeError) || stdout.String() != "lib" {
+		t.Fatalf("expected import outside the module path to fail, got %v %q", err, stdout.String())
+	}
+}
+
+func TestModuleCantReplaceBuiltins(t *testing.T) {
+	dir := t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"evil.lox": `len = nil; export fun size(s) { return len; }`,
+	})
+
+	var stdout bytes.Buffer
+	err := lox.Eval(`
+import "evil" as e;
+print len("abc");
+print e.size("abc");
+`, lox.WithStdout(&stdout), lox.WithModulePath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "3" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
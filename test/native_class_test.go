@@ -0,0 +1,136 @@
+package test
+
+import (
+	"errors"
+	"lox_go/lox"
+	"strings"
+	"testing"
+)
+
+type request struct {
+	Path    string
+	Retries int
+	secret  string
+}
+
+func (r *request) Describe(prefix string) string {
+	return prefix + r.Path
+}
+
+func (r *request) Secret() string {
+	return r.secret
+}
+
+func (r *request) Split() (string, string, string) {
+	return r.Path, "", ""
+}
+
+func TestNativeClass(t *testing.T) {
+	class, err := lox.NewNativeClass("Request", func(path string) *request {
+		return &request{Path: path, secret: "hidden"}
+	}, "Path", "Retries", "Describe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+	vm.DefineClass(class)
+	host := &request{Path: "/host"}
+	wrapped, err := class.Wrap(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.DefineGlobal("host", wrapped)
+
+	var got []string
+	vm.DefineNative("record", func(s string) { got = append(got, s) })
+
+	err = vm.Run(`
+var r = Request("/index");
+r.Retries = r.Retries + 3;
+record(r.Describe("GET "));
+host.Path = "/changed";
+
+class Logged < Request {
+  init(path) {
+    super.init("/logged" + path);
+    this.count = 1;
+  }
+  describe() {
+    return "[" + this.count + "] " + super.Describe("POST ");
+  }
+}
+var l = Logged("/x");
+record(l.describe());
+l.Retries = 2;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "|") != "GET /index|[1] POST /logged/x" {
+		t.Fatalf("unexpected output %q", got)
+	}
+	if host.Path != "/changed" {
+		t.Fatalf("host object not updated: %q", host.Path)
+	}
+
+	cases := map[string]string{
+		`host.Secret();`:      "Undefined property 'Secret'.",
+		`host.secret = 1;`:    "Undefined field 'secret'.",
+		`host.Retries = "a";`: "Field 'Retries' must be an integer.",
+	}
+	for code, message := range cases {
+		var runtimeError *lox.RuntimeError
+		if err := vm.Run(code); !errors.As(err, &runtimeError) || runtimeError.Message != message {
+			t.Errorf("%s: got %v, want %q", code, err, message)
+		}
+	}
+
+	if _, err := lox.NewNativeClass("Bad", func() *request { return nil }, "secret"); err == nil {
+		t.Fatal("expected error for unexported member")
+	}
+	if _, err := lox.NewNativeClass("Bad", func() *request { return nil }, "Split"); err == nil {
+		t.Fatal("expected error for unsupported method signature")
+	}
+	if _, err := class.Wrap(nil); err == nil {
+		t.Fatal("expected error when wrapping nil")
+	}
+	if _, err := class.Wrap((*request)(nil)); err == nil {
+		t.Fatal("expected error when wrapping a nil pointer")
+	}
+}
+
+const codeNativeGrandchild = `
+class Middle < Request {}
+class Leaf < Middle {
+  init(path) {
+    super.init("/leaf" + path);
+  }
+  describe() {
+    return super.Describe("PUT ");
+  }
+}
+var leaf = Leaf("/x");
+record(leaf.describe());
+record(leaf.Path);
+`
+
+func TestNativeClassGrandchild(t *testing.T) {
+	class, err := lox.NewNativeClass("Request", func(path string) *request {
+		return &request{Path: path}
+	}, "Path", "Describe")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+	vm.DefineClass(class)
+	var got []string
+	vm.DefineNative("record", func(s string) { got = append(got, s) })
+	if err := vm.Run(codeNativeGrandchild); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, "|") != "PUT /leaf/x|/leaf/x" {
+		t.Fatalf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"lox_go/lox"
+	"strings"
+	"testing"
+)
+
+func TestDefineNative(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+	var got []interface{}
+	if err := vm.DefineNative("record", func(value interface{}) { got = append(got, value) }); err != nil {
+		t.Fatal(err)
+	}
+	vm.DefineNative("add", func(a, b float64) float64 { return a + b })
+	vm.DefineNative("join", func(sep string, parts ...string) string { return strings.Join(parts, sep) })
+	vm.DefineNative("repeat", func(s string, n int) string { return strings.Repeat(s, n) })
+	vm.DefineNative("check", func(ok bool) (string, error) {
+		if !ok {
+			return "", fmt.Errorf("check failed.")
+		}
+		return "ok", nil
+	})
+
+	err := vm.Run(`
+record(add(1, 2));
+record(join("-", "a", "b", "c"));
+record(join(","));
+record(repeat("ab", 2));
+record(check(true));
+record(nil);
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{3.0, "a-b-c", "", "abab", "ok", nil}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	cases := map[string]string{
+		`add(1, "2");`:      "Argument 2 of 'add' must be a number.",
+		`add(1);`:           "Expected 2 arguments but got 1.",
+		`join();`:           "Expected at least 1 arguments but got 0.",
+		`repeat("a", 1.5);`: "Argument 2 of 'repeat' must be an integer.",
+		`check(false);`:     "check failed.",
+	}
+	for code, message := range cases {
+		var runtimeError *lox.RuntimeError
+		if err := vm.Run(code); !errors.As(err, &runtimeError) || runtimeError.Message != message {
+			t.Errorf("%s: got %v, want %q", code, err, message)
+		} else if runtimeError.Token.Lexeme() != ")" {
+			t.Errorf("%s: error reported at %q", code, runtimeError.Token.Lexeme())
+		}
+	}
+
+	if err := vm.DefineNative("bad", 42); err == nil {
+		t.Fatal("expected error for non-function native")
+	}
+}
@@ -0,0 +1,243 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"math/big"
+	"testing"
+)
+
+func TestNumberLiterals(t *testing.T) {
+	cases := []struct {
+		code   string
+		output string
+	}{
+		{`print 0xFF;`, "255"},
+		{`print 0b1010;`, "10"},
+		{`print 0o755;`, "493"},
+		{`print 1_000_000;`, "1000000"},
+		{`print 0xFF_FF;`, "65535"},
+		{`print 1.5e3;`, "1500"},
+		{`print 25E-1;`, "2.5"},
+		{`print 1e+2 + 1;`, "101"},
+	}
+	for _, c := range cases {
+		var stdout bytes.Buffer
+		if err := lox.Eval(c.code, lox.WithStdout(&stdout)); err != nil {
+			t.Fatalf("%s: %v", c.code, err)
+		}
+		if stdout.String() != c.output {
+			t.Errorf("%s: expected %q, got %q", c.code, c.output, stdout.String())
+		}
+	}
+}
+
+func TestMalformedNumbers(t *testing.T) {
+	cases := []struct {
+		code    string
+		message string
+		lexeme  string
+	}{
+		{`var a = 0xFG;`, `Invalid digit 'G' in hexadecimal literal.`, "0xFG"},
+		{`var a = 0b102;`, `Invalid digit '2' in binary literal.`, "0b102"},
+		{`var a = 0x;`, `Missing digits after '0x'.`, "0x"},
+		{`var a = 1e;`, `Missing digits in exponent.`, "1e"},
+		{`var a = 1_000_;`, `Invalid '_' in number literal, it must be between digits.`, "1_000_"},
+		{`var a = 1__0;`, `Invalid '_' in number literal, it must be between digits.`, "1__0"},
+		{`var a = 123abc;`, `Invalid character 'a' in number literal.`, "123abc"},
+		{`var a = 1e999;`, `Number literal out of range.`, "1e999"},
+		{`var a = 1e999999999m;`, `Number literal out of range.`, "1e999999999m"},
+		{`var a = 1e-99999999999999999999m;`, `Number literal out of range.`, "1e-99999999999999999999m"},
+	}
+	for _, c := range cases {
+		var errorList lox.ErrorList
+		if err := lox.Eval(c.code, lox.WithErrorReporter(nil)); !errors.As(err, &errorList) {
+			t.Fatalf("%s: expected static errors, got %v", c.code, err)
+		}
+		if len(errorList) != 1 {
+			t.Fatalf("%s: expected exactly one error, got %v", c.code, errorList)
+		}
+		scanError, ok := errorList[0].(*lox.ScanError)
+		if !ok {
+			t.Fatalf("%s: expected *lox.ScanError, got %T", c.code, errorList[0])
+		}
+		if scanError.Message != c.message || scanError.Lexeme != c.lexeme || scanError.Column != 9 {
+			t.Errorf("%s: got %v at column %d (%q)", c.code, scanError, scanError.Column, scanError.Lexeme)
+		}
+	}
+}
+
+func TestIntegerArithmetic(t *testing.T) {
+	cases := []struct {
+		code   string
+		output string
+	}{
+		{`print 9007199254740993;`, "9007199254740993"},
+		{`print 9007199254740992 + 1;`, "9007199254740993"},
+		{`print 7 / 2;`, "3.5"},
+		{`print 7 ~/ 2;`, "3"},
+		{`print -7 ~/ 2;`, "-3"},
+		{`print 7 % 3;`, "1"},
+		{`print -7 % 3;`, "-1"},
+		{`print 7.5 % 2;`, "1.5"},
+		{`print 7.5 ~/ 2;`, "3"},
+		{`print 1 + 0.5;`, "1.5"},
+		{`print 1 == 1.0;`, "true"},
+		{`print 9007199254740993 == 9007199254740992.0;`, "false"},
+		{`print 2 < 2.5;`, "true"},
+		{`print "id:" + 12345678901234567;`, "id:12345678901234567"},
+		{`print -0x7FFF_FFFF_FFFF_FFFF - 1;`, "-9223372036854775808"},
+		{`print 99999999999999999999;`, "99999999999999999999"},
+	}
+	for _, c := range cases {
+		var stdout bytes.Buffer
+		if err := lox.Eval(c.code, lox.WithStdout(&stdout)); err != nil {
+			t.Fatalf("%s: %v", c.code, err)
+		}
+		if stdout.String() != c.output {
+			t.Errorf("%s: expected %q, got %q", c.code, c.output, stdout.String())
+		}
+	}
+}
+
+func TestIntegerErrors(t *testing.T) {
+	cases := []runtimeErrorCase{
+		{`print 1 ~/ 0;`, "Division by zero.", 1},
+		{`print 1 % 0;`, "Division by zero.", 1},
+	}
+	expectRuntimeErrors(t, cases)
+}
+
+func TestIntegerNatives(t *testing.T) {
+	var stdout bytes.Buffer
+	vm := lox.NewVM(lox.WithStdout(&stdout), lox.WithErrorReporter(nil))
+	vm.DefineNative("next", func(id int64) int64 { return id + 1 })
+	vm.DefineNative("byte", func(b uint8) uint8 { return b })
+	if err := vm.Run(`print next(9007199254740992);`); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "9007199254740993" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+
+	var runtimeError *lox.RuntimeError
+	if err := vm.Run(`byte(256);`); !errors.As(err, &runtimeError) {
+		t.Fatalf("expected runtime error, got %v", err)
+	}
+}
+
+func TestBigIntAndDecimal(t *testing.T) {
+	cases := []struct {
+		code   string
+		output string
+	}{
+		{`print 0x7FFF_FFFF_FFFF_FFFFn + 1;`, "9223372036854775808"},
+		{`print 123456789012345678901234567890;`, "123456789012345678901234567890"},
+		{`print 0x1_0000_0000_0000_0000;`, "18446744073709551616"},
+		{`print 9223372036854775808 == 0x7FFF_FFFF_FFFF_FFFF + 1;`, "true"},
+		{`print 2n * 9223372036854775807;`, "18446744073709551614"},
+		{`print 10n ~/ 3;`, "3"},
+		{`print -10n % 3n;`, "-1"},
+		{`print 1n / 4;`, "0.25"},
+		{`print 1n / 4 + 0.5;`, "0.75"},
+		{`var max = 0x7FFF_FFFF_FFFF_FFFF; print (max + 1) / 2 + 0.5;`, "4611686018427388000"},
+		{`print 0.1m + 0.2m;`, "0.3"},
+		{`print 0.1m + 0.2m == 0.3m;`, "true"},
+		{`print 1.10m + 1;`, "2.10"},
+		{`print 19.99m * 3;`, "59.97"},
+		{`print 1m / 3;`, "0.33333333333333333333"},
+		{`print 2m / 3;`, "0.66666666666666666667"},
+		{`print 10m / 4;`, "2.5"},
+		{`print 7.5m ~/ 2;`, "3"},
+		{`print 7.5m % 2;`, "1.5"},
+		{`print -1.5e2m;`, "-150"},
+		{`print 1.5e-3m;`, "0.0015"},
+		{`print 1 == 1n and 1n == 1.00m and 1.00m == 1.0;`, "true"},
+		{`print 0.1m == 0.1;`, "false"},
+		{`print 3n > 2.5 and 2.5m < 3n;`, "true"},
+		{`print 1n + 0.5;`, "1.5"},
+		{`print "total: " + 12.50m;`, "total: 12.50"},
+		{`print bigint("0xFF") + bigint(2.0) + bigint(3.00m);`, "260"},
+		{`print decimal(0.1) + decimal("0.2") + decimal(1n);`, "1.3"},
+	}
+	for _, c := range cases {
+		var stdout bytes.Buffer
+		if err := lox.Eval(c.code, lox.WithStdout(&stdout)); err != nil {
+			t.Fatalf("%s: %v", c.code, err)
+		}
+		if stdout.String() != c.output {
+			t.Errorf("%s: expected %q, got %q", c.code, c.output, stdout.String())
+		}
+	}
+}
+
+func TestBigIntAndDecimalErrors(t *testing.T) {
+	runtimeCases := []runtimeErrorCase{
+		{`print 1.5m + 0.5;`, "Cannot mix decimal and float operands, convert with decimal() first.", 1},
+		{`print 1n / 0;`, "Division by zero.", 1},
+		{`print 1.5m % 0m;`, "Division by zero.", 1},
+		{`print bigint(1.5);`, "Cannot convert 1.5 to bigint.", 1},
+		{`print decimal("abc");`, "Invalid decimal 'abc'.", 1},
+	}
+	expectRuntimeErrors(t, runtimeCases)
+
+	var errorList lox.ErrorList
+	err := lox.Eval(`print 1.5n;`, lox.WithErrorReporter(nil))
+	if !errors.As(err, &errorList) || errorList[0].(*lox.ScanError).Message != "Bigint literal must be an integer." {
+		t.Errorf("expected bigint literal error, got %v", err)
+	}
+}
+
+func TestBigNativeConversion(t *testing.T) {
+	var stdout bytes.Buffer
+	vm := lox.NewVM(lox.WithStdout(&stdout), lox.WithErrorReporter(nil))
+	vm.DefineNative("square", func(x *big.Int) *big.Int { return new(big.Int).Mul(x, x) })
+	vm.DefineNative("price", func() *lox.Decimal {
+		d, _ := lox.NewDecimal("9.95")
+		return d
+	})
+	if err := vm.Run(`print square(4294967296); print " "; print price() * 2;`); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "18446744073709551616 19.90" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+const codeIntegerPromotion = `
+var f = 1;
+for (var i = 1; i <= 25; i = i + 1) f = f * i;
+print f;
+var max = 0x7FFF_FFFF_FFFF_FFFF;
+var min = -max - 1;
+print " " + (max + 1);
+print " " + (min - 1);
+print " " + (max * 2);
+print " " + -min;
+print " " + (min ~/ -1);
+print " ";
+print max + 1 - 1 == max;
+print " " + math.pow(10, 19);
+print " " + math.abs(min);
+`
+
+func TestIntegerOverflowPromotesToBigInt(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(codeIntegerPromotion, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	want := "15511210043330985984000000 9223372036854775808 -9223372036854775809 18446744073709551614" +
+		" 9223372036854775808 9223372036854775808 true 10000000000000000000 9223372036854775808"
+	if stdout.String() != want {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestMultiplicationSizeLimit(t *testing.T) {
+	expectRuntimeErrors(t, []runtimeErrorCase{
+		{`var x = 99999999999; while (true) x = x * x;`, "Result of multiplication is too large.", 1},
+		{`var x = 99999999999n; while (true) x = x * x;`, "Result of multiplication is too large.", 1},
+		{`var x = 1.5m; while (true) x = x * x;`, "Result of multiplication is too large.", 1},
+	})
+}
@@ -0,0 +1,39 @@
+package test
+
+import (
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+const codeManyTypos = `
+var a = ;
+print a
+var b = 1;
+fun f(a b) {}
+class C { m() { print ; } }
+if (b > 1 print b;
+print "still parsed";
+`
+
+func TestParserReportsEveryError(t *testing.T) {
+	var errorList lox.ErrorList
+	err := lox.Eval(codeManyTypos, lox.WithErrorReporter(nil))
+	if !errors.As(err, &errorList) {
+		t.Fatalf("expected static errors, got %v", err)
+	}
+
+	wantLines := []int{2, 4, 5, 6, 7}
+	if len(errorList) != len(wantLines) {
+		t.Fatalf("got %d errors, want %d:\n%v", len(errorList), len(wantLines), errorList)
+	}
+	for index, err := range errorList {
+		parseError, ok := err.(*lox.ParseError)
+		if !ok {
+			t.Fatalf("error %d: expected *lox.ParseError, got %T", index, err)
+		}
+		if parseError.Line != wantLines[index] {
+			t.Errorf("error %d: got line %d, want %d: %v", index, parseError.Line, wantLines[index], parseError)
+		}
+	}
+}
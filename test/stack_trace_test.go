@@ -0,0 +1,57 @@
+package test
+
+import (
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+const codeStackTrace = `
+class Calculator {
+  divide(a, b) {
+    return check(b) / a;
+  }
+}
+
+fun check(b) {
+  return b - nil;
+}
+
+fun run() {
+  return Calculator().divide(1, 2);
+}
+
+run();
+`
+
+func TestStackTrace(t *testing.T) {
+	var runtimeError *lox.RuntimeError
+	err := lox.Eval(codeStackTrace, lox.WithErrorReporter(nil))
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("expected runtime error, got %v", err)
+	}
+
+	want := "[line 9] in check()\n" +
+		"[line 4] in Calculator.divide()\n" +
+		"[line 13] in run()\n" +
+		"[line 16] in script\n"
+	if trace := runtimeError.StackTrace(); trace != want {
+		t.Fatalf("unexpected trace:\n%s", trace)
+	}
+}
+
+func TestStackTraceFromGo(t *testing.T) {
+	vm := lox.NewVM(lox.WithErrorReporter(nil))
+	if err := vm.Run(`fun fail() { return nil + 1; }`); err != nil {
+		t.Fatal(err)
+	}
+	fail, _ := vm.GetGlobal("fail")
+
+	var runtimeError *lox.RuntimeError
+	if _, err := vm.Call(fail); !errors.As(err, &runtimeError) {
+		t.Fatalf("expected runtime error, got %v", err)
+	}
+	if trace := runtimeError.StackTrace(); trace != "[line 1] in fail()\n" {
+		t.Fatalf("unexpected trace:\n%s", trace)
+	}
+}
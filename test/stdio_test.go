@@ -0,0 +1,51 @@
+package test
+
+import (
+	"bytes"
+	"lox_go/lox"
+	"strings"
+	"testing"
+)
+
+func TestStdoutCapture(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(codeFunctionClosure, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "1212" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestStderrReporting(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := lox.Eval(`print "a"; print 1 - nil;`, lox.WithStdout(&stdout), lox.WithStderr(&stderr))
+	if err == nil {
+		t.Fatal("expected runtime error")
+	}
+	if stdout.String() != "a" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+	want := "[line 1]Operands must be a numbers.\n" +
+		" --> 1:20\n" +
+		"1 | print \"a\"; print 1 - nil;\n" +
+		"  |                    ^\n" +
+		"[line 1] in script\n"
+	if stderr.String() != want {
+		t.Fatalf("unexpected error output %q", stderr.String())
+	}
+}
+
+func TestRunPromptStreams(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	stdin := strings.NewReader("var a = 1;\nprint a + 1;\nprint b;\n")
+	vm := lox.NewVM(lox.WithStdin(stdin), lox.WithStdout(&stdout), lox.WithStderr(&stderr))
+	vm.RunPrompt()
+
+	if stdout.String() != "> \n> 2\n> \n> " {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "Undefined variable 'b'.") {
+		t.Fatalf("unexpected error output %q", stderr.String())
+	}
+}
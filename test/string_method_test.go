@@ -0,0 +1,55 @@
+package test
+
+import (
+	"bytes"
+	"lox_go/lox"
+	"testing"
+)
+
+const codeStringMethods = `
+var s = "  Hello, 世界  ".trim();
+print s.len() + len(s);
+print "|" + s[7] + s.substring(0, 5) + s.substring(7);
+print "|" + s.indexOf("世") + s.indexOf("l", 3) + s.indexOf("x");
+var parts = "a,b,,c".split(",");
+print "|" + len(parts) + "-".join(parts) + "".join(["x", 1, true]);
+print "|" + "a.b.a".replace("a", "o") + s.upper() + s.lower();
+print "|";
+print s.startsWith("Hell") and s.endsWith("界") and s.contains(", ") and !s.contains("!");
+print "|" + "ab".repeat(3) + "".repeat(5) + len("abc".split(""));
+var upper = "x".upper;
+print "|" + upper();
+`
+
+func TestStringMethods(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(codeStringMethods, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	want := "18|世Hello世界|73-1|4a-b--cx1true|o.b.oHELLO, 世界hello, 世界|true|ababab3|X"
+	if stdout.String() != want {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestStringMethodErrors(t *testing.T) {
+	cases := []runtimeErrorCase{
+		{`"abc"[3];`, "String index 3 is out of range for length 3.", 1},
+		{`"abc"[1.5];`, "String index must be an integer.", 1},
+		{`var s = "abc"; s[0] = "x";`, "Strings are immutable.", 1},
+		{`"abc".substring(2, 1);`, "Substring start 2 is after end 1.", 1},
+		{`"abc".split(1);`, "Argument 1 of 'split' must be a string.", 1},
+		{`",".join("abc");`, "Argument 1 of 'join' must be a list.", 1},
+		{`"a".repeat(-1);`, "Repeat count can't be negative.", 1},
+		{`"ab".repeat(4611686018427387904);`, "Result of repeat is too long.", 1},
+		{`"ab".repeat(200000000);`, "Result of repeat is too long.", 1},
+		{`var s = "aaaa"; while (true) s = s.replace("a", s);`, "Result of replace is too long.", 1},
+		{`var s = "a".repeat(100000000); ",".join([s, s, s]);`, "Result of join is too long.", 1},
+		{`var s = "a".repeat(100000000); while (true) s = s + s;`, "Result of concatenation is too long.", 1},
+		{`"a".reverse();`, "Undefined property 'reverse'.", 1},
+		{`"a".substring();`, "Expected 1 to 2 arguments but got 0.", 1},
+		{`len(3);`, "Can't get the length of 3.", 1},
+		{`len(len);`, "Can't get the length of <native fn len>.", 1},
+	}
+	expectRuntimeErrors(t, cases)
+}
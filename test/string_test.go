@@ -0,0 +1,60 @@
+package test
+
+import (
+	"bytes"
+	"lox_go/lox"
+	"testing"
+)
+
+func TestStringEscapes(t *testing.T) {
+	var stdout bytes.Buffer
+	err := lox.Eval(`print "a\tb\n\"q\" \\ \u{4F60}\u{1F600}";`, lox.WithStdout(&stdout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "a\tb\n\"q\" \\ 你😀" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+
+	stdout.Reset()
+	if err := lox.Eval(`print r"C:\new\table";`, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != `C:\new\table` {
+		t.Fatalf("unexpected raw output %q", stdout.String())
+	}
+}
+
+const codeTripleQuoted = `
+fun poem() {
+  return """
+    Roses are "red",
+      violets\tblue.
+
+    Done.
+    """;
+}
+print poem();
+print """one line""";
+`
+
+func TestTripleQuotedStrings(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := lox.Eval(codeTripleQuoted, lox.WithStdout(&stdout)); err != nil {
+		t.Fatal(err)
+	}
+	want := "Roses are \"red\",\n  violets\tblue.\n\nDone.one line"
+	if stdout.String() != want {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestMalformedEscapes(t *testing.T) {
+	expectStaticErrors(t, []staticErrorCase{
+		{`var a = "ok\qno";`, `Invalid escape sequence '\q'.`, 1, 12},
+		{`var a = "\u{110000}";`, `Invalid unicode escape '\u{110000}'.`, 1, 10},
+		{`var a = "\u0041";`, `Expect '{' and '}' around unicode escape.`, 1, 10},
+		{"var a = \"\"\"\n  x\n  \\z\n  \"\"\";", `Invalid escape sequence '\z'.`, 3, 3},
+		{`var a = "open;`, `Unterminated string.`, 1, 9},
+	})
+}
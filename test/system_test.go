@@ -0,0 +1,110 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const codeFileSystem = `
+var dir = os.argv[0];
+fs.mkdir(dir + "/out/nested");
+fs.writeFile(dir + "/out/report.txt", "line 1\n");
+fs.appendFile(dir + "/out/report.txt", "line 2\n");
+print fs.readFile(dir + "/out/report.txt");
+print ",".join(fs.listDir(dir + "/out"));
+print fs.exists(dir + "/out/report.txt");
+fs.remove(dir + "/out/report.txt");
+print fs.exists(dir + "/out/report.txt");
+os.setenv("LOX_SYSTEM_TEST", "on");
+print os.getenv("LOX_SYSTEM_TEST") + " " + len(os.argv);
+print os.getenv("LOX_SYSTEM_TEST_MISSING") == nil;
+`
+
+func TestFileSystemModule(t *testing.T) {
+	dir := t.TempDir()
+
+	var stdout bytes.Buffer
+	err := lox.Eval(codeFileSystem, lox.WithStdout(&stdout), lox.WithSystemAccess(true), lox.WithArgs(dir, "extra"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "line 1\nline 2\nnested,report.txttruefalseon 2true"
+	if stdout.String() != want {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "nested")); err != nil {
+		t.Fatal(err)
+	}
+	// os.setenv 只影响这个 VM
+	if _, ok := os.LookupEnv("LOX_SYSTEM_TEST"); ok {
+		t.Fatal("os.setenv changed the host environment")
+	}
+}
+
+func TestFileSystemErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	var runtimeError *lox.RuntimeError
+	err := lox.Eval(`fs.readFile(os.argv[0]);`, lox.WithErrorReporter(nil), lox.WithSystemAccess(true), lox.WithArgs(missing))
+	if !errors.As(err, &runtimeError) || runtimeError.Message != "Can't read '"+missing+"': no such file or directory." {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	var stdout bytes.Buffer
+	err = lox.Eval(`
+try { fs.remove(os.argv[0]); } catch (e) { print "caught"; }
+`, lox.WithStdout(&stdout), lox.WithSystemAccess(true), lox.WithArgs(missing))
+	if err != nil || stdout.String() != "caught" {
+		t.Fatalf("unexpected result %v %q", err, stdout.String())
+	}
+}
+
+func TestExit(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := lox.Eval(`
+try {
+  os.exit(3);
+} catch (e) {
+  print "caught";
+} finally {
+  print "finally";
+}
+print "after";
+`, lox.WithStdout(&stdout), lox.WithStderr(&stderr), lox.WithSystemAccess(true))
+
+	var exitError *lox.ExitError
+	if !errors.As(err, &exitError) || exitError.Code != 3 {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if stdout.String() != "finally" || stderr.Len() != 0 {
+		t.Fatalf("unexpected output %q %q", stdout.String(), stderr.String())
+	}
+
+	vm := lox.NewVM(lox.WithStdin(strings.NewReader("print 1;\nos.exit();\nprint 2;\n")), lox.WithStdout(&stdout), lox.WithSystemAccess(true))
+	stdout.Reset()
+	if err := vm.RunPrompt(); !errors.As(err, &exitError) || exitError.Code != 0 {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if stdout.String() != "> 1\n> " {
+		t.Fatalf("unexpected prompt output %q", stdout.String())
+	}
+}
+
+// 默认不允许访问文件系统和进程环境
+func TestSystemAccessDisabled(t *testing.T) {
+	cases := []string{
+		`fs.readFile("/etc/hostname");`,
+		`import "os" as o;`,
+	}
+	for _, code := range cases {
+		var runtimeError *lox.RuntimeError
+		err := lox.Eval(code, lox.WithErrorReporter(nil))
+		if !errors.As(err, &runtimeError) {
+			t.Errorf("%s: expected a runtime error, got %v", code, err)
+		}
+	}
+}
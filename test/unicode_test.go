@@ -0,0 +1,51 @@
+package test
+
+import (
+	"bytes"
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	var stdout bytes.Buffer
+	err := lox.Eval("\uFEFFvar 名字 = \"世界\";\nvar café_1 = 1;\nfun 问候(人) { return \"你好，\" + 人; }\nprint 问候(名字) + café_1;",
+		lox.WithStdout(&stdout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "你好，世界1" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+}
+
+func TestUnicodeColumns(t *testing.T) {
+	var runtimeError *lox.RuntimeError
+	err := lox.Eval("var 名字 = \"世界\"; print 名字 - 1;", lox.WithErrorReporter(nil))
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("expected runtime error, got %v", err)
+	}
+	if runtimeError.Token.Column() != 25 || runtimeError.Token.Offset() != 36 {
+		t.Fatalf("unexpected position column %d offset %d", runtimeError.Token.Column(), runtimeError.Token.Offset())
+	}
+	want := " --> 1:25\n" +
+		"1 | var 名字 = \"世界\"; print 名字 - 1;\n" +
+		"  |                         ^\n"
+	if snippet := runtimeError.Snippet(); snippet != want {
+		t.Fatalf("unexpected snippet:\n%s", snippet)
+	}
+
+	var errorList lox.ErrorList
+	err = lox.Eval("var a = \"é\" § 1;\nvar b = \xff;", lox.WithErrorReporter(nil))
+	if !errors.As(err, &errorList) || len(errorList) < 2 {
+		t.Fatalf("expected scan errors, got %v", err)
+	}
+	first := errorList[0].(*lox.ScanError)
+	if first.Column != 13 || first.Lexeme != "§" || first.Message != "Unexpected character." {
+		t.Fatalf("unexpected first error %d %q %q", first.Column, first.Lexeme, first.Message)
+	}
+	second := errorList[1].(*lox.ScanError)
+	if second.Line != 2 || second.Column != 9 || second.Message != "Invalid UTF-8 encoding." {
+		t.Fatalf("unexpected second error %d:%d %q", second.Line, second.Column, second.Message)
+	}
+}
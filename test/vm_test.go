@@ -0,0 +1,71 @@
+package test
+
+import (
+	"errors"
+	"lox_go/lox"
+	"testing"
+)
+
+func TestVMIsolation(t *testing.T) {
+	vm1 := lox.NewVM()
+	vm2 := lox.NewVM()
+
+	if err := vm1.Run(`var a = 1;`); err != nil {
+		t.Fatal(err)
+	}
+	if err := vm1.Run(`a = a + 1;`); err != nil {
+		t.Fatal(err)
+	}
+
+	var runtimeError *lox.RuntimeError
+	if err := vm2.Run(`print a;`); !errors.As(err, &runtimeError) {
+		t.Fatalf("expected runtime error, got %v", err)
+	}
+}
+
+func TestVMErrorDoesNotLeak(t *testing.T) {
+	vm := lox.NewVM()
+
+	var errorList lox.ErrorList
+	if err := vm.Run(`var = ;`); !errors.As(err, &errorList) {
+		t.Fatalf("expected static errors, got %v", err)
+	}
+	if err := vm.Run(`var b = 2;`); err != nil {
+		t.Fatal(err)
+	}
+
+	vm.Reset()
+	if err := vm.Run(`print b;`); err == nil {
+		t.Fatal("expected undefined variable after reset")
+	}
+}
+
+func TestVMStructuredErrors(t *testing.T) {
+	collector := &lox.ErrorCollector{}
+	vm := lox.NewVM(lox.WithErrorReporter(collector))
+
+	err := vm.Run("var a = 1;\nprint a @;")
+	var errorList lox.ErrorList
+	if !errors.As(err, &errorList) || len(errorList) == 0 {
+		t.Fatalf("expected static errors, got %v", err)
+	}
+	scanError, ok := errorList[0].(*lox.ScanError)
+	if !ok {
+		t.Fatalf("expected *lox.ScanError, got %T", errorList[0])
+	}
+	if scanError.Line != 2 || scanError.Column != 9 || scanError.Lexeme != "@" {
+		t.Fatalf("unexpected position: %d:%d %q", scanError.Line, scanError.Column, scanError.Lexeme)
+	}
+	if len(collector.Errors) != len(errorList) {
+		t.Fatalf("reporter saw %d errors, want %d", len(collector.Errors), len(errorList))
+	}
+
+	err = vm.Run(`print 1 - "a";`)
+	var runtimeError *lox.RuntimeError
+	if !errors.As(err, &runtimeError) {
+		t.Fatalf("expected runtime error, got %v", err)
+	}
+	if runtimeError.Token.Lexeme() != "-" || runtimeError.Token.Column() != 9 {
+		t.Fatalf("unexpected token: %q at column %d", runtimeError.Token.Lexeme(), runtimeError.Token.Column())
+	}
+}
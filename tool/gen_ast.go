@@ -21,10 +21,15 @@ func main() {
 		"AssignExpr   : name *Token, value Expr",
 		"BinaryExpr   : left Expr, operator *Token, right Expr",
 		"CallExpr     : callee Expr, paren *Token, arguments []Expr",
+		"FunctionExpr : keyword *Token, declaration *FunctionStmt",
 		"GetExpr      : object Expr, name *Token",
 		"GroupingExpr : expression Expr",
+		"IndexExpr    : object Expr, bracket *Token, index Expr",
+		"IndexSetExpr : object Expr, bracket *Token, index Expr, value Expr",
+		"ListExpr     : bracket *Token, elements []Expr",
 		"LiteralExpr  : value interface{}",
 		"LogicalExpr  : left Expr, operator *Token, right Expr",
+		"MapExpr      : brace *Token, keys []Expr, values []Expr",
 		"SetExpr	  : object Expr, name *Token, value Expr",
 		"SuperExpr	  : keyword *Token, method *Token",
 		"ThisExpr     : keyword *Token",
@@ -34,15 +39,21 @@ func main() {
 
 	defineAst(outputDir, "Stmt", []string{
 		"BlockStmt      : statements []Stmt",
+		"BreakStmt      : keyword *Token, label *Token",
 		"ClassStmt      : name *Token, superclass *VariableExpr, methods []*FunctionStmt",
+		"ContinueStmt   : keyword *Token, label *Token",
+		"ExportStmt     : keyword *Token, declaration Stmt, names []*Token",
 		"ExpressionStmt : expression Expr",
 		"FunctionStmt   : name *Token, params []*Token, body []Stmt",
 		"IfStmt         : condition Expr, thenBranch Stmt," +
 			" elseBranch Stmt",
+		"ImportStmt     : keyword *Token, path *Token, alias *Token, names []*Token, aliases []*Token",
 		"PrintStmt      : expression Expr",
 		"ReturnStmt     : keyword *Token, value Expr",
+		"ThrowStmt      : keyword *Token, value Expr",
+		"TryStmt        : keyword *Token, body []Stmt, catchName *Token, catchBody []Stmt, finallyBody []Stmt",
 		"VarStmt    : name *Token, initializer Expr",
-		"WhileStmt  : condition Expr, body Stmt",
+		"WhileStmt  : keyword *Token, condition Expr, body Stmt, increment Expr, label *Token",
 	})
 }
 